@@ -0,0 +1,76 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v3 contains the subset of the projectcalico.org/v3 API which is needed to graph Calico network policies.
+package v3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// GroupName is the group name used in this package.
+const GroupName = "projectcalico.org"
+
+// SchemeGroupVersion is the group version used in this package.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v3"}
+
+// CRDGroupVersion is the group version of the custom resources backing this API.
+var CRDGroupVersion = schema.GroupVersion{Group: "crd.projectcalico.org", Version: "v1"}
+
+// NetworkPolicy is a namespaced Calico network policy.
+type NetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NetworkPolicySpec `json:"spec,omitempty"`
+}
+
+// GlobalNetworkPolicy is a cluster scoped Calico network policy.
+type GlobalNetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NetworkPolicySpec `json:"spec,omitempty"`
+}
+
+// NetworkPolicySpec contains the specification of a NetworkPolicy or GlobalNetworkPolicy.
+type NetworkPolicySpec struct {
+	Tier              string   `json:"tier,omitempty"`
+	Order             *float64 `json:"order,omitempty"`
+	Selector          string   `json:"selector,omitempty"`
+	NamespaceSelector string   `json:"namespaceSelector,omitempty"`
+	Types             []string `json:"types,omitempty"`
+	Ingress           []Rule   `json:"ingress,omitempty"`
+	Egress            []Rule   `json:"egress,omitempty"`
+}
+
+// Rule is a single rule of a policy.
+type Rule struct {
+	Action      string              `json:"action"`
+	Protocol    *intstr.IntOrString `json:"protocol,omitempty"`
+	Source      EntityRule          `json:"source,omitempty"`
+	Destination EntityRule          `json:"destination,omitempty"`
+}
+
+// EntityRule matches the source or destination of traffic.
+type EntityRule struct {
+	Nets              []string             `json:"nets,omitempty"`
+	NotNets           []string             `json:"notNets,omitempty"`
+	Selector          string               `json:"selector,omitempty"`
+	NamespaceSelector string               `json:"namespaceSelector,omitempty"`
+	Ports             []intstr.IntOrString `json:"ports,omitempty"`
+	Domains           []string             `json:"domains,omitempty"`
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v2 contains the subset of the cilium.io/v2 API which is needed to graph Cilium network policies.
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the group name used in this package.
+const GroupName = "cilium.io"
+
+// SchemeGroupVersion is the group version used in this package.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v2"}
+
+// CiliumNetworkPolicy is a namespaced Cilium network policy.
+type CiliumNetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec  *Rule  `json:"spec,omitempty"`
+	Specs []Rule `json:"specs,omitempty"`
+}
+
+// CiliumClusterwideNetworkPolicy is a cluster scoped Cilium network policy.
+type CiliumClusterwideNetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec  *Rule  `json:"spec,omitempty"`
+	Specs []Rule `json:"specs,omitempty"`
+}
+
+// Rule is a policy rule which applies to all endpoints selected by the EndpointSelector.
+type Rule struct {
+	EndpointSelector *metav1.LabelSelector `json:"endpointSelector,omitempty"`
+	NodeSelector     *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	Ingress          []IngressRule         `json:"ingress,omitempty"`
+	IngressDeny      []IngressRule         `json:"ingressDeny,omitempty"`
+	Egress           []EgressRule          `json:"egress,omitempty"`
+	EgressDeny       []EgressRule          `json:"egressDeny,omitempty"`
+}
+
+// IngressRule contains all rule types which can be applied at ingress.
+type IngressRule struct {
+	FromEndpoints []metav1.LabelSelector `json:"fromEndpoints,omitempty"`
+	FromCIDR      []string               `json:"fromCIDR,omitempty"`
+	FromCIDRSet   []CIDRRule             `json:"fromCIDRSet,omitempty"`
+	FromEntities  []string               `json:"fromEntities,omitempty"`
+	ToPorts       []PortRule             `json:"toPorts,omitempty"`
+}
+
+// EgressRule contains all rule types which can be applied at egress.
+type EgressRule struct {
+	ToEndpoints []metav1.LabelSelector `json:"toEndpoints,omitempty"`
+	ToCIDR      []string               `json:"toCIDR,omitempty"`
+	ToCIDRSet   []CIDRRule             `json:"toCIDRSet,omitempty"`
+	ToEntities  []string               `json:"toEntities,omitempty"`
+	ToFQDNs     []FQDNSelector         `json:"toFQDNs,omitempty"`
+	ToPorts     []PortRule             `json:"toPorts,omitempty"`
+}
+
+// CIDRRule is a CIDR prefix with an optional list of excluded CIDR prefixes.
+type CIDRRule struct {
+	Cidr        string   `json:"cidr"`
+	ExceptCIDRs []string `json:"except,omitempty"`
+}
+
+// FQDNSelector selects DNS names either by exact name or by a wildcard pattern.
+type FQDNSelector struct {
+	MatchName    string `json:"matchName,omitempty"`
+	MatchPattern string `json:"matchPattern,omitempty"`
+}
+
+// PortRule is a list of ports and protocols which are allowed.
+type PortRule struct {
+	Ports []PortProtocol `json:"ports,omitempty"`
+}
+
+// PortProtocol specifies a port or port range and its protocol.
+type PortProtocol struct {
+	Port     string `json:"port,omitempty"`
+	EndPort  int32  `json:"endPort,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+}
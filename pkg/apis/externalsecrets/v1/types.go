@@ -0,0 +1,110 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1 contains the subset of the external-secrets.io/v1 API which is needed to graph External Secrets Operator resources.
+// The same subset is also used for external-secrets.io/v1beta1.
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the group name used in this package.
+const GroupName = "external-secrets.io"
+
+// SchemeGroupVersion is the group version used in this package.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1"}
+
+// BetaGroupVersion is the previous group version which shares the same subset.
+var BetaGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1beta1"}
+
+// ExternalSecret fetches data from a secret store and writes it into a Secret.
+type ExternalSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ExternalSecretSpec `json:"spec"`
+}
+
+// ExternalSecretSpec is the spec of an ExternalSecret.
+type ExternalSecretSpec struct {
+	SecretStoreRef SecretStoreRef           `json:"secretStoreRef,omitempty"`
+	Target         ExternalSecretTarget     `json:"target,omitempty"`
+	Data           []ExternalSecretData     `json:"data,omitempty"`
+	DataFrom       []ExternalSecretDataFrom `json:"dataFrom,omitempty"`
+}
+
+// SecretStoreRef references a SecretStore or ClusterSecretStore.
+type SecretStoreRef struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// ExternalSecretTarget defines the Secret which is created, by default with the name of the ExternalSecret.
+type ExternalSecretTarget struct {
+	Name           string `json:"name,omitempty"`
+	CreationPolicy string `json:"creationPolicy,omitempty"`
+}
+
+// ExternalSecretData maps a remote key to a key of the target Secret.
+type ExternalSecretData struct {
+	SecretKey string                      `json:"secretKey"`
+	RemoteRef ExternalSecretDataRemoteRef `json:"remoteRef"`
+}
+
+// ExternalSecretDataRemoteRef is the key of a secret in the provider.
+type ExternalSecretDataRemoteRef struct {
+	Key string `json:"key"`
+}
+
+// ExternalSecretDataFrom fetches all keys of a remote secret or all secrets which match a query.
+type ExternalSecretDataFrom struct {
+	Extract *ExternalSecretDataRemoteRef `json:"extract,omitempty"`
+	Find    *ExternalSecretFind          `json:"find,omitempty"`
+}
+
+// ExternalSecretFind finds secrets in the provider by their name, path or tags.
+type ExternalSecretFind struct {
+	Path *string           `json:"path,omitempty"`
+	Name *FindName         `json:"name,omitempty"`
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// FindName matches the names of secrets in the provider with a regular expression.
+type FindName struct {
+	RegExp string `json:"regexp,omitempty"`
+}
+
+// SecretStore is a namespaced store of external secrets.
+type SecretStore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SecretStoreSpec `json:"spec"`
+}
+
+// ClusterSecretStore is a store of external secrets which can be used from any namespace.
+type ClusterSecretStore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SecretStoreSpec `json:"spec"`
+}
+
+// SecretStoreSpec is the spec of a SecretStore or ClusterSecretStore.
+// The provider contains exactly one key which names the provider, like aws or vault.
+type SecretStoreSpec struct {
+	Provider map[string]interface{} `json:"provider"`
+}
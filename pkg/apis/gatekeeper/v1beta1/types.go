@@ -0,0 +1,76 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1beta1 contains the subset of the constraints.gatekeeper.sh/v1beta1 API which is needed to graph Gatekeeper constraints.
+// The kind of a constraint is defined by its ConstraintTemplate, so all constraints share the same type.
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the group name used in this package.
+const GroupName = "constraints.gatekeeper.sh"
+
+// SchemeGroupVersion is the group version used in this package.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1beta1"}
+
+// Constraint is an instance of a ConstraintTemplate.
+type Constraint struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConstraintSpec   `json:"spec,omitempty"`
+	Status ConstraintStatus `json:"status,omitempty"`
+}
+
+// ConstraintSpec selects the resources a constraint applies to.
+type ConstraintSpec struct {
+	Match             Match  `json:"match,omitempty"`
+	EnforcementAction string `json:"enforcementAction,omitempty"`
+}
+
+// Match selects resources by kind, namespace, name and labels. An empty match selects all resources.
+type Match struct {
+	Kinds              []Kinds               `json:"kinds,omitempty"`
+	Scope              string                `json:"scope,omitempty"`
+	Namespaces         []string              `json:"namespaces,omitempty"`
+	ExcludedNamespaces []string              `json:"excludedNamespaces,omitempty"`
+	LabelSelector      *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	NamespaceSelector  *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	Name               string                `json:"name,omitempty"`
+}
+
+// Kinds selects kinds within API groups. An empty list or "*" selects all.
+type Kinds struct {
+	APIGroups []string `json:"apiGroups,omitempty"`
+	Kinds     []string `json:"kinds,omitempty"`
+}
+
+// ConstraintStatus contains the violations found by the last audit.
+type ConstraintStatus struct {
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Violation is a resource which violates a constraint.
+type Violation struct {
+	Group             string `json:"group,omitempty"`
+	Version           string `json:"version,omitempty"`
+	Kind              string `json:"kind"`
+	Namespace         string `json:"namespace,omitempty"`
+	Name              string `json:"name"`
+	Message           string `json:"message,omitempty"`
+	EnforcementAction string `json:"enforcementAction,omitempty"`
+}
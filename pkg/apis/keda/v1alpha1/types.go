@@ -0,0 +1,113 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1alpha1 contains the subset of the keda.sh/v1alpha1 API which is needed to graph KEDA resources.
+package v1alpha1
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the group name used in this package.
+const GroupName = "keda.sh"
+
+// SchemeGroupVersion is the group version used in this package.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// ScaledObject scales a workload based on its triggers.
+type ScaledObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ScaledObjectSpec `json:"spec"`
+}
+
+// ScaledObjectSpec is the spec of a ScaledObject.
+type ScaledObjectSpec struct {
+	ScaleTargetRef *ScaleTarget    `json:"scaleTargetRef"`
+	Triggers       []ScaleTriggers `json:"triggers"`
+}
+
+// ScaleTarget references the workload which is scaled, by default an apps/v1 Deployment.
+type ScaleTarget struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name"`
+}
+
+// ScaledJob creates jobs based on its triggers.
+type ScaledJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ScaledJobSpec `json:"spec"`
+}
+
+// ScaledJobSpec is the spec of a ScaledJob.
+type ScaledJobSpec struct {
+	JobTargetRef *batchv1.JobSpec `json:"jobTargetRef,omitempty"`
+	Triggers     []ScaleTriggers  `json:"triggers"`
+}
+
+// ScaleTriggers is a trigger with its scaler type and metadata.
+type ScaleTriggers struct {
+	Type              string             `json:"type"`
+	Name              string             `json:"name,omitempty"`
+	Metadata          map[string]string  `json:"metadata"`
+	AuthenticationRef *AuthenticationRef `json:"authenticationRef,omitempty"`
+}
+
+// AuthenticationRef references a TriggerAuthentication or ClusterTriggerAuthentication.
+type AuthenticationRef struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// TriggerAuthentication defines how a trigger can authenticate in its namespace.
+type TriggerAuthentication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TriggerAuthenticationSpec `json:"spec"`
+}
+
+// ClusterTriggerAuthentication defines how a trigger can authenticate in any namespace.
+type ClusterTriggerAuthentication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TriggerAuthenticationSpec `json:"spec"`
+}
+
+// TriggerAuthenticationSpec is the spec of a TriggerAuthentication or ClusterTriggerAuthentication.
+type TriggerAuthenticationSpec struct {
+	SecretTargetRef    []AuthSecretTargetRef    `json:"secretTargetRef,omitempty"`
+	ConfigMapTargetRef []AuthConfigMapTargetRef `json:"configMapTargetRef,omitempty"`
+}
+
+// AuthSecretTargetRef maps a key of a Secret to a trigger parameter.
+type AuthSecretTargetRef struct {
+	Parameter string `json:"parameter"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+}
+
+// AuthConfigMapTargetRef maps a key of a ConfigMap to a trigger parameter.
+type AuthConfigMapTargetRef struct {
+	Parameter string `json:"parameter"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1 contains the subset of the kyverno.io/v1 API which is needed to graph Kyverno policies.
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the group name used in this package.
+const GroupName = "kyverno.io"
+
+// SchemeGroupVersion is the group version used in this package.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1"}
+
+// ClusterPolicy is a Kyverno policy which applies to resources in all namespaces.
+type ClusterPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec Spec `json:"spec"`
+}
+
+// Policy is a Kyverno policy which only applies to resources in its own namespace.
+type Policy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec Spec `json:"spec"`
+}
+
+// Spec contains the rules of a policy.
+type Spec struct {
+	Rules                   []Rule `json:"rules,omitempty"`
+	ValidationFailureAction string `json:"validationFailureAction,omitempty"`
+}
+
+// Rule selects the resources it applies to with match and exclude.
+type Rule struct {
+	Name    string          `json:"name"`
+	Match   MatchResources  `json:"match,omitempty"`
+	Exclude *MatchResources `json:"exclude,omitempty"`
+}
+
+// MatchResources selects resources by any or all resource filters, or by a single resource description.
+type MatchResources struct {
+	Any       []ResourceFilter    `json:"any,omitempty"`
+	All       []ResourceFilter    `json:"all,omitempty"`
+	Resources ResourceDescription `json:"resources,omitempty"`
+}
+
+// ResourceFilter is a single resource description within any or all.
+type ResourceFilter struct {
+	Resources ResourceDescription `json:"resources,omitempty"`
+}
+
+// ResourceDescription selects resources by kind, name, namespace and labels. Names and namespaces may contain wildcards.
+type ResourceDescription struct {
+	Kinds             []string              `json:"kinds,omitempty"`
+	Name              string                `json:"name,omitempty"`
+	Names             []string              `json:"names,omitempty"`
+	Namespaces        []string              `json:"namespaces,omitempty"`
+	Selector          *metav1.LabelSelector `json:"selector,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
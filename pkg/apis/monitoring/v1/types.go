@@ -0,0 +1,103 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1 contains the subset of the monitoring.coreos.com/v1 API which is needed to graph Prometheus Operator resources.
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// GroupName is the group name used in this package.
+const GroupName = "monitoring.coreos.com"
+
+// SchemeGroupVersion is the group version used in this package.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1"}
+
+// Prometheus defines a Prometheus deployment.
+type Prometheus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PrometheusSpec `json:"spec"`
+}
+
+// PrometheusSpec selects the monitors and rules which are loaded by a Prometheus.
+type PrometheusSpec struct {
+	ServiceAccountName              string                `json:"serviceAccountName,omitempty"`
+	ServiceMonitorSelector          *metav1.LabelSelector `json:"serviceMonitorSelector,omitempty"`
+	ServiceMonitorNamespaceSelector *metav1.LabelSelector `json:"serviceMonitorNamespaceSelector,omitempty"`
+	PodMonitorSelector              *metav1.LabelSelector `json:"podMonitorSelector,omitempty"`
+	PodMonitorNamespaceSelector     *metav1.LabelSelector `json:"podMonitorNamespaceSelector,omitempty"`
+	RuleSelector                    *metav1.LabelSelector `json:"ruleSelector,omitempty"`
+	RuleNamespaceSelector           *metav1.LabelSelector `json:"ruleNamespaceSelector,omitempty"`
+}
+
+// ServiceMonitor defines how a set of services should be monitored.
+type ServiceMonitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ServiceMonitorSpec `json:"spec"`
+}
+
+// ServiceMonitorSpec selects the services and their endpoints which are scraped.
+type ServiceMonitorSpec struct {
+	Endpoints         []Endpoint           `json:"endpoints"`
+	Selector          metav1.LabelSelector `json:"selector"`
+	NamespaceSelector NamespaceSelector    `json:"namespaceSelector,omitempty"`
+}
+
+// Endpoint is a scrapeable endpoint of a service.
+type Endpoint struct {
+	Port       string              `json:"port,omitempty"`
+	TargetPort *intstr.IntOrString `json:"targetPort,omitempty"`
+	Path       string              `json:"path,omitempty"`
+}
+
+// PodMonitor defines how a set of pods should be monitored.
+type PodMonitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PodMonitorSpec `json:"spec"`
+}
+
+// PodMonitorSpec selects the pods and their endpoints which are scraped.
+type PodMonitorSpec struct {
+	PodMetricsEndpoints []PodMetricsEndpoint `json:"podMetricsEndpoints"`
+	Selector            metav1.LabelSelector `json:"selector"`
+	NamespaceSelector   NamespaceSelector    `json:"namespaceSelector,omitempty"`
+}
+
+// PodMetricsEndpoint is a scrapeable endpoint of a pod.
+type PodMetricsEndpoint struct {
+	Port       string              `json:"port,omitempty"`
+	TargetPort *intstr.IntOrString `json:"targetPort,omitempty"`
+	Path       string              `json:"path,omitempty"`
+}
+
+// NamespaceSelector selects the namespaces of monitored objects, by default only the namespace of the monitor.
+type NamespaceSelector struct {
+	Any        bool     `json:"any,omitempty"`
+	MatchNames []string `json:"matchNames,omitempty"`
+}
+
+// PrometheusRule defines recording and alerting rules for a Prometheus.
+type PrometheusRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+}
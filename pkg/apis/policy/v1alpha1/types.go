@@ -0,0 +1,123 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1alpha1 contains the subset of the policy.networking.k8s.io/v1alpha1 API which is needed
+// to graph AdminNetworkPolicy and BaselineAdminNetworkPolicy resources.
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the group name used in this package.
+const GroupName = "policy.networking.k8s.io"
+
+// SchemeGroupVersion is the group version used in this package.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// AdminNetworkPolicy is a cluster scoped network policy which takes precedence over NetworkPolicy objects.
+type AdminNetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AdminNetworkPolicySpec `json:"spec"`
+}
+
+// AdminNetworkPolicySpec defines the desired state of an AdminNetworkPolicy.
+type AdminNetworkPolicySpec struct {
+	Priority int32         `json:"priority"`
+	Subject  Subject       `json:"subject"`
+	Ingress  []IngressRule `json:"ingress,omitempty"`
+	Egress   []EgressRule  `json:"egress,omitempty"`
+}
+
+// BaselineAdminNetworkPolicy is a cluster scoped network policy which is evaluated after NetworkPolicy objects.
+type BaselineAdminNetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec BaselineAdminNetworkPolicySpec `json:"spec"`
+}
+
+// BaselineAdminNetworkPolicySpec defines the desired state of a BaselineAdminNetworkPolicy.
+type BaselineAdminNetworkPolicySpec struct {
+	Subject Subject       `json:"subject"`
+	Ingress []IngressRule `json:"ingress,omitempty"`
+	Egress  []EgressRule  `json:"egress,omitempty"`
+}
+
+// Subject selects the pods a policy applies to, either by namespace or by namespace and pod.
+type Subject struct {
+	Namespaces *metav1.LabelSelector `json:"namespaces,omitempty"`
+	Pods       *NamespacedPod        `json:"pods,omitempty"`
+}
+
+// NamespacedPod selects pods by namespace and pod labels.
+type NamespacedPod struct {
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+	PodSelector       metav1.LabelSelector `json:"podSelector"`
+}
+
+// IngressRule describes an action to take on traffic from a set of peers.
+type IngressRule struct {
+	Name   string        `json:"name,omitempty"`
+	Action string        `json:"action"`
+	From   []IngressPeer `json:"from"`
+	Ports  *[]Port       `json:"ports,omitempty"`
+}
+
+// IngressPeer selects the sources of ingress traffic.
+type IngressPeer struct {
+	Namespaces *metav1.LabelSelector `json:"namespaces,omitempty"`
+	Pods       *NamespacedPod        `json:"pods,omitempty"`
+}
+
+// EgressRule describes an action to take on traffic to a set of peers.
+type EgressRule struct {
+	Name   string       `json:"name,omitempty"`
+	Action string       `json:"action"`
+	To     []EgressPeer `json:"to"`
+	Ports  *[]Port      `json:"ports,omitempty"`
+}
+
+// EgressPeer selects the destinations of egress traffic.
+type EgressPeer struct {
+	Namespaces  *metav1.LabelSelector `json:"namespaces,omitempty"`
+	Pods        *NamespacedPod        `json:"pods,omitempty"`
+	Nodes       *metav1.LabelSelector `json:"nodes,omitempty"`
+	Networks    []string              `json:"networks,omitempty"`
+	DomainNames []string              `json:"domainNames,omitempty"`
+}
+
+// Port describes how to select destination ports, either by number, by name or by range.
+type Port struct {
+	PortNumber *PortNumber `json:"portNumber,omitempty"`
+	NamedPort  *string     `json:"namedPort,omitempty"`
+	PortRange  *PortRange  `json:"portRange,omitempty"`
+}
+
+// PortNumber selects a single port number and protocol.
+type PortNumber struct {
+	Protocol v1.Protocol `json:"protocol"`
+	Port     int32       `json:"port"`
+}
+
+// PortRange selects a range of port numbers and a protocol.
+type PortRange struct {
+	Protocol v1.Protocol `json:"protocol,omitempty"`
+	Start    int32       `json:"start"`
+	End      int32       `json:"end"`
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1alpha2 contains the subset of the wgpolicyk8s.io/v1alpha2 API which is needed to graph policy reports.
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the group name used in this package.
+const GroupName = "wgpolicyk8s.io"
+
+// SchemeGroupVersion is the group version used in this package.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
+
+// PolicyReport contains the policy results for namespaced resources.
+type PolicyReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Scope   *corev1.ObjectReference `json:"scope,omitempty"`
+	Results []PolicyReportResult    `json:"results,omitempty"`
+}
+
+// ClusterPolicyReport contains the policy results for cluster scoped resources.
+type ClusterPolicyReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Scope   *corev1.ObjectReference `json:"scope,omitempty"`
+	Results []PolicyReportResult    `json:"results,omitempty"`
+}
+
+// PolicyReportResult is the result of a policy rule for a list of resources.
+type PolicyReportResult struct {
+	Source    string                   `json:"source,omitempty"`
+	Policy    string                   `json:"policy"`
+	Rule      string                   `json:"rule,omitempty"`
+	Result    string                   `json:"result,omitempty"`
+	Severity  string                   `json:"severity,omitempty"`
+	Message   string                   `json:"message,omitempty"`
+	Resources []corev1.ObjectReference `json:"resources,omitempty"`
+}
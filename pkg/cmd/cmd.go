@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
@@ -10,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/templates"
 
@@ -34,8 +39,30 @@ var (
 		# Visualize resources from a directory with kustomization.yaml - e.g. dir/kustomization.yaml.
 		%[1]s graph -k dir/ | dot -T svg -o kustomization.svg
 
+		# Simulate networkpolicies from a directory with kustomization.yaml without contacting the server.
+		%[1]s graph -k dir/ --local --reachability=workloads -o matrix
+
 		# Visualize all pods and networkpolicies together in graphviz output format.
-		%[1]s graph networkpolicies | dot -T svg -o networkpolicies.svg`)
+		%[1]s graph networkpolicies | dot -T svg -o networkpolicies.svg
+
+		# Print which deployments can reach each other based on all networkpolicies and pods in a namespace.
+		%[1]s graph networkpolicies,pods --reachability=workloads -o matrix
+
+		# Visualize all deployments and pods together with their latest warning events.
+		%[1]s graph deployments,pods --with-events | dot -T svg -o deployments.svg
+
+		# Report dangling references and orphaned objects of local manifests in SARIF format.
+		%[1]s graph -f dir/ --local --lint -o sarif
+
+		# Save a snapshot of all resources of a namespace and render it later without cluster access.
+		%[1]s graph all -n kube-system -o json > snapshot.json
+		%[1]s graph --from-snapshot snapshot.json -o cypher
+
+		# Visualize all pods of all namespaces and retrieve related objects through informer caches.
+		%[1]s graph pods -A --informers -o cypher
+
+		# Visualize all resources of a namespace, but print the partial graph after at most one minute.
+		%[1]s graph all -n kube-system --timeout=1m --request-timeout=10s -o cypher`)
 )
 
 // GraphOptions contains the input to the graph command.
@@ -45,13 +72,22 @@ type GraphOptions struct {
 	AllNamespaces     bool
 	ChunkSize         int64
 	CmdParent         string
+	Concurrency       int
 	ExplicitNamespace bool
 	FieldSelector     string
+	FromSnapshot      string
+	Informers         bool
 	LabelSelector     string
+	Lint              bool
+	Local             bool
 	Namespace         string
 	Namespaces        []string
 	OutputFormat      string
+	Reachability      string
+	Timeout           time.Duration
 	Truncate          int
+	WithEvents        bool
+	EventWindow       time.Duration
 
 	resource.FilenameOptions
 	genericclioptions.IOStreams
@@ -64,7 +100,9 @@ func NewGraphOptions(parent string, flags *genericclioptions.ConfigFlags, stream
 		CmdParent:   parent,
 		IOStreams:   streams,
 		ChunkSize:   500,
+		Concurrency: graph.DefaultConcurrency,
 		Truncate:    graph.DefaultNodeNameLimit,
+		EventWindow: graph.DefaultEventWindow,
 	}
 }
 
@@ -74,11 +112,13 @@ func NewCmdGraph(parent string, flags *genericclioptions.ConfigFlags, streams ge
 	o := NewGraphOptions(parent, flags, streams)
 
 	cmd := &cobra.Command{
-		Use:                   fmt.Sprintf("%s graph [(-o|--output=)aql|arangodb|cql|cypher|dot|graphviz|mermaid] (TYPE[.VERSION][.GROUP] ...) [flags]", parent),
+		Use:                   "graph [(-o|--output=)aql|arangodb|cql|cypher|dot|graphviz|json|matrix|mermaid] (TYPE[.VERSION][.GROUP] ...) [flags]",
 		DisableFlagsInUseLine: true,
+		Annotations:           map[string]string{cobra.CommandDisplayNameAnnotation: parent + " graph"},
 		Short:                 "Visualize one or many resources and relationships",
 		Long:                  graphLong + "\n\n" + cmdutil.SuggestAPIResources(parent),
 		Example:               fmt.Sprintf(graphExample, parent),
+		Args:                  cobra.ArbitraryArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(o.Complete(f, cmd, args))
 			cmdutil.CheckErr(o.Validate(cmd, args))
@@ -87,16 +127,33 @@ func NewCmdGraph(parent string, flags *genericclioptions.ConfigFlags, streams ge
 	}
 
 	cmd.Flags().BoolP("help", "h", false, fmt.Sprintf("Help for %s graph", parent))
+	cmd.Flags().BoolVar(&o.Lint, "lint", o.Lint, "If true, dangling references and orphaned objects are reported instead of a graph. Output format one of: text|json|sarif.")
+	cmd.Flags().StringVar(&o.FromSnapshot, "from-snapshot", o.FromSnapshot, "If present, the graph is read from a snapshot file written with -o json instead of resolving resources. Use - to read from stdin.")
+	cmd.Flags().StringVarP(&o.OutputFormat, "output", "o", o.OutputFormat, "Output format. One of: aql|arangodb|cql|cypher|dot|graphviz|json|matrix|mermaid.")
+	cmd.Flags().BoolVar(&o.WithEvents, "with-events", o.WithEvents, "If true, warning events are fetched and attached to the nodes they are regarding.")
+	cmd.Flags().DurationVar(&o.EventWindow, "events-window", o.EventWindow, "The window in which warning events are considered recent with --with-events.")
+	cmd.Flags().StringVar(&o.Reachability, "reachability", o.Reachability, "Evaluate all networkpolicies and add CanReach relationships between pods or workloads. One of: pods|workloads.")
+	cmd.Flags().Lookup("reachability").NoOptDefVal = "pods"
+	o.AddResourceFlags(cmd)
+	o.configFlags.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(NewCmdDiff(parent, flags, streams))
+
+	return cmd
+}
+
+// AddResourceFlags adds the flags to select and resolve the resources of a graph to the command.
+func (o *GraphOptions) AddResourceFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", o.AllNamespaces, "If present, list the requested object(s) across all namespaces. Namespace in current context is ignored even if specified with --namespace.")
 	cmd.Flags().Int64Var(&o.ChunkSize, "chunk-size", o.ChunkSize, "Return large lists in chunks rather than all at once. Pass 0 to disable.")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "Number of objects which are resolved concurrently. Related objects are retrieved only once per run.")
+	cmd.Flags().BoolVar(&o.Informers, "informers", o.Informers, "If true, related objects are served from shared informer caches, which list and watch each kind once instead of requesting single objects.")
+	cmd.Flags().BoolVar(&o.Local, "local", o.Local, "If true, relationships are resolved from the given files only and the server is not contacted.")
 	cmd.Flags().IntVarP(&o.Truncate, "truncate", "t", o.Truncate, "Truncate node name to N characters. This affects graphviz and mermaid output format.")
 	cmd.Flags().StringVar(&o.FieldSelector, "field-selector", o.FieldSelector, "Selector (field query) to filter on, supports '=', '==', and '!='.(e.g. --field-selector key1=value1,key2=value2). The server only supports a limited number of field queries per type.")
 	cmd.Flags().StringVarP(&o.LabelSelector, "selector", "l", o.LabelSelector, "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
-	cmd.Flags().StringVarP(&o.OutputFormat, "output", "o", o.OutputFormat, "Output format. One of: aql|arangodb|cql|cypher|dot|graphviz|mermaid.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The length of time to wait for the graph before the partial graph is printed. Zero means no timeout. Single requests are bounded by --request-timeout.")
 	cmdutil.AddFilenameOptionFlags(cmd, &o.FilenameOptions, "identifying the resource to get from a server.")
-	o.configFlags.AddFlags(cmd.Flags())
-
-	return cmd
 }
 
 // Complete takes the command arguments and factory and infers any remaining options.
@@ -113,6 +170,18 @@ func (o *GraphOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []st
 		o.ExplicitNamespace = false
 	}
 
+	if o.FromSnapshot != "" && !cmd.Flags().Changed("truncate") {
+		// keep the node name limit of the snapshot
+		o.Truncate = 0
+	}
+
+	if o.Lint {
+		if o.OutputFormat == "" {
+			o.OutputFormat = "text"
+		}
+		return nil
+	}
+
 	switch o.OutputFormat {
 	case "aql":
 		o.OutputFormat = "arangodb"
@@ -120,6 +189,10 @@ func (o *GraphOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []st
 		o.OutputFormat = "cypher"
 	case "dot", "":
 		o.OutputFormat = "graphviz"
+	case "matrix":
+		if o.Reachability == "" {
+			o.Reachability = "pods"
+		}
 	}
 
 	return nil
@@ -127,63 +200,186 @@ func (o *GraphOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []st
 
 // Validate checks the set of flags provided by the user.
 func (o *GraphOptions) Validate(cmd *cobra.Command, args []string) error {
-	if len(args) == 0 && cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize) {
+	if err := o.ValidateResources(args); err != nil {
+		return err
+	}
+	if o.FromSnapshot != "" && (o.Lint || o.Reachability != "" || o.WithEvents) {
+		return fmt.Errorf("--from-snapshot can not be combined with --lint, --reachability or --with-events")
+	}
+	if o.Lint {
+		if !(o.OutputFormat == "text" || o.OutputFormat == "json" || o.OutputFormat == "sarif") {
+			return fmt.Errorf("invalid output format: %q, allowed formats with --lint are: %s", o.OutputFormat, "text|json|sarif")
+		}
+		if o.Reachability != "" {
+			return fmt.Errorf("--reachability can not be combined with --lint")
+		}
+		return nil
+	}
+	if !(o.OutputFormat == "arangodb" || o.OutputFormat == "cypher" || o.OutputFormat == "graphviz" || o.OutputFormat == "json" || o.OutputFormat == "matrix" || o.OutputFormat == "mermaid") {
+		return fmt.Errorf("invalid output format: %q, allowed formats are: %s", o.OutputFormat, "aql|arangodb|cql|cypher|dot|graphviz|json|matrix|mermaid")
+	}
+	if !(o.Reachability == "" || o.Reachability == "pods" || o.Reachability == "workloads") {
+		return fmt.Errorf("invalid reachability: %q, allowed values are: %s", o.Reachability, "pods|workloads")
+	}
+
+	return nil
+}
+
+// ValidateResources checks the flags which select and resolve the resources of a graph.
+func (o *GraphOptions) ValidateResources(args []string) error {
+	if o.FromSnapshot != "" && (len(args) != 0 || !cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize)) {
+		return fmt.Errorf("resources can not be specified when a snapshot is read")
+	}
+	if len(args) == 0 && o.FromSnapshot == "" && cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize) {
 		return fmt.Errorf("you must specify the type of resource to graph. %s", cmdutil.SuggestAPIResources(o.CmdParent))
 	}
-	if !(o.OutputFormat == "arangodb" || o.OutputFormat == "cypher" || o.OutputFormat == "graphviz" || o.OutputFormat == "mermaid") {
-		return fmt.Errorf("invalid output format: %q, allowed formats are: %s", o.OutputFormat, "aql|arangodb|cql|cypher|dot|graphviz|mermaid")
+	if o.Local && (len(args) != 0 || cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize)) {
+		return fmt.Errorf("you must specify resources by --filename or --kustomize when --local is set")
+	}
+	if o.Local && o.Informers {
+		return fmt.Errorf("--informers can not be combined with --local")
+	}
+	if o.Concurrency < 1 {
+		return fmt.Errorf("invalid concurrency: %d, must be at least 1", o.Concurrency)
+	}
+	if o.Timeout < 0 {
+		return fmt.Errorf("invalid timeout: %s, must not be negative", o.Timeout)
+	}
+	if o.EventWindow <= 0 {
+		return fmt.Errorf("invalid events window: %s, must be positive", o.EventWindow)
 	}
 
 	return nil
 }
 
-// Run performs the graph operation.
+// Run performs the graph operation. On SIGINT or when the timeout is reached, the partial graph is printed.
 func (o *GraphOptions) Run(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
-	config, err := f.ToRESTConfig()
-	if err != nil {
+	ctx, cancel := o.Context()
+	defer cancel()
+
+	graph, err := o.Graph(ctx, f, args)
+	if err != nil && (graph == nil || ctx.Err() == nil) {
 		return err
 	}
+	defer graph.WriteWarnings(o.ErrOut)
 
-	fmt.Fprintf(o.ErrOut, "Please wait while retrieving data from %s\n", config.Host)
-
-	clientset, err := f.KubernetesClientSet()
-	if err != nil {
+	if ctx.Err() != nil {
+		// the report and the matrix of a partial graph would be misleading, so only the graph is printed
+		fmt.Fprint(o.ErrOut, "\n")
+		if o.Lint || o.OutputFormat == "matrix" {
+			return err
+		}
+		if writeErr := graph.Write(o.Out, o.OutputFormat); writeErr != nil {
+			return writeErr
+		}
 		return err
 	}
 
-	objs := []*unstructured.Unstructured{}
-	for _, namespace := range o.Namespaces {
-		r := f.NewBuilder().
-			Unstructured().
-			NamespaceParam(namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
-			FilenameParam(o.ExplicitNamespace, &o.FilenameOptions).
-			LabelSelectorParam(o.LabelSelector).
-			FieldSelectorParam(o.FieldSelector).
-			RequestChunksOf(o.ChunkSize).
-			ResourceTypeOrNameArgs(true, args...).
-			ContinueOnError().
-			Latest().
-			Flatten().
-			Do()
+	if o.Lint {
+		report, err := graph.Lint()
+		if err != nil {
+			return err
+		}
+		if err := report.Write(o.Out, o.OutputFormat); err != nil {
+			return err
+		}
+		if errors := report.Errors(); errors != 0 {
+			return fmt.Errorf("found %d error(s)", errors)
+		}
+		return nil
+	}
 
-		if err := r.Err(); err != nil {
+	if o.WithEvents {
+		if err := graph.Events(o.EventWindow); err != nil {
 			return err
 		}
+	}
 
-		infos, err := r.Infos()
+	if o.Reachability != "" {
+		matrix, err := graph.NetworkingV1().Reachability(o.Reachability == "workloads")
 		if err != nil {
 			return err
 		}
 
-		for _, info := range infos {
-			objs = append(objs, info.Object.(*unstructured.Unstructured))
+		if o.OutputFormat == "matrix" {
+			return matrix.Write(o.Out)
 		}
 	}
 
+	return graph.Write(o.Out, o.OutputFormat)
+}
+
+// Context returns the context of a run, which is canceled on SIGINT or when the timeout is reached.
+func (o *GraphOptions) Context() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		// restore the default behavior, so a second SIGINT terminates immediately
+		<-ctx.Done()
+		stop()
+	}()
+
+	if o.Timeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// Graph resolves the graph of the given resources or reads it from a snapshot. When the context is done,
+// the partial graph is returned together with an error.
+func (o *GraphOptions) Graph(ctx context.Context, f cmdutil.Factory, args []string) (*graph.Graph, error) {
+	if o.FromSnapshot != "" {
+		return o.Snapshot()
+	}
+
+	host := "local manifests"
+	var source graph.ObjectSource
+
+	if !o.Local {
+		config, err := f.ToRESTConfig()
+		if err != nil {
+			return nil, err
+		}
+		host = config.Host
+
+		fmt.Fprintf(o.ErrOut, "Please wait while retrieving data from %s\n", host)
+
+		client, err := f.DynamicClient()
+		if err != nil {
+			return nil, err
+		}
+
+		mapper, err := f.ToRESTMapper()
+		if err != nil {
+			return nil, err
+		}
+
+		server, _, err := rest.DefaultServerUrlFor(config)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Informers {
+			// the informers are stopped when the run is finished or canceled
+			source = graph.NewInformerSource(client, mapper, server.Hostname(), 0, ctx.Done())
+		} else {
+			source = graph.NewLiveSource(client, mapper, server.Hostname())
+		}
+	}
+
+	objs, err := o.Objects(ctx, f, args)
+	if err != nil {
+		return nil, err
+	}
+
 	bar := progressbar.NewOptions(len(objs),
 		progressbar.OptionSetDescription("Processing..."),
 		progressbar.OptionSetWriter(o.ErrOut),
-		progressbar.OptionSetWidth(10+len(config.Host)),
+		progressbar.OptionSetWidth(10+len(host)),
 		progressbar.OptionShowCount(),
 		progressbar.OptionSetTheme(progressbar.Theme{
 			Saucer:        "=",
@@ -198,14 +394,94 @@ func (o *GraphOptions) Run(f cmdutil.Factory, cmd *cobra.Command, args []string)
 		}),
 	)
 
-	graph, err := graph.NewGraph(clientset, objs, func() { bar.Add(1) })
-	if err != nil {
-		return err
+	options := &graph.Options{
+		NodeNameLimit: o.Truncate,
+		Concurrency:   o.Concurrency,
+		AllNamespaces: o.AllNamespaces,
+	}
+
+	return graph.NewGraph(ctx, source, objs, options, func() { bar.Add(1) })
+}
+
+// Objects retrieves the objects to graph from the server or the given files. It returns when the context is done,
+// even though the requests are not canceled.
+func (o *GraphOptions) Objects(ctx context.Context, f cmdutil.Factory, args []string) ([]*unstructured.Unstructured, error) {
+	type result struct {
+		objs []*unstructured.Unstructured
+		err  error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		objs, err := o.objects(f, args)
+		done <- result{objs: objs, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.objs, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// objects retrieves the objects to graph for all namespaces.
+func (o *GraphOptions) objects(f cmdutil.Factory, args []string) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	for _, namespace := range o.Namespaces {
+		b := f.NewBuilder().
+			Unstructured().
+			LocalParam(o.Local).
+			NamespaceParam(namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
+			FilenameParam(o.ExplicitNamespace, &o.FilenameOptions).
+			LabelSelectorParam(o.LabelSelector).
+			FieldSelectorParam(o.FieldSelector).
+			RequestChunksOf(o.ChunkSize).
+			ResourceTypeOrNameArgs(true, args...).
+			ContinueOnError()
+
+		if !o.Local {
+			b = b.Latest()
+		}
+
+		r := b.Flatten().Do()
+
+		if err := r.Err(); err != nil {
+			return nil, err
+		}
+
+		infos, err := r.Infos()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, info := range infos {
+			objs = append(objs, info.Object.(*unstructured.Unstructured))
+		}
 	}
 
+	return objs, nil
+}
+
+// Snapshot reads the graph from the snapshot file, - reads from stdin.
+func (o *GraphOptions) Snapshot() (*graph.Graph, error) {
+	in := o.In
+	if o.FromSnapshot != "-" {
+		file, err := os.Open(o.FromSnapshot)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	graph, err := graph.ReadSnapshot(in)
+	if err != nil {
+		return nil, err
+	}
 	if o.Truncate > 0 {
 		graph.Options.NodeNameLimit = o.Truncate
 	}
 
-	return graph.Write(o.Out, o.OutputFormat)
+	return graph, nil
 }
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveteuber/kubectl-graph/pkg/graph"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	diffLong = templates.LongDesc(`
+		Compare the graph of resources with a base graph and report added, removed and changed nodes and
+		relationships. Nodes are compared by apiVersion, kind, namespace and name.
+
+		The base graph is read from a snapshot, resolved from local manifests or resolved from the same
+		resources in another context. With a graph output format, a combined graph is printed where
+		additions are green, removals red and changes yellow, cypher and aql tag them with a diff property.`)
+
+	diffExample = templates.Examples(`
+		# Report what changed in a namespace since a snapshot was taken.
+		%[1]s graph all -n app -o json > before.json
+		%[1]s graph diff all -n app --from-snapshot before.json
+
+		# Compare two snapshots and visualize the changes in graphviz output format.
+		%[1]s graph diff --from-snapshot before.json --to-snapshot after.json -o dot | dot -T svg -o diff.svg
+
+		# Compare the resources of a namespace with the manifests of the next release.
+		%[1]s graph diff all -n app --from-filename dir/ -o cypher | cypher-shell -u neo4j -p secret
+
+		# Compare the resources of a namespace between two contexts.
+		%[1]s graph diff all -n app --context production --from-context staging`)
+)
+
+// DiffOptions contains the input to the graph diff command.
+type DiffOptions struct {
+	*GraphOptions
+
+	BaseContext   string
+	BaseFilenames []string
+	BaseSnapshot  string
+}
+
+// NewDiffOptions returns a DiffOptions with the defaults of GraphOptions.
+func NewDiffOptions(parent string, flags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *DiffOptions {
+	return &DiffOptions{
+		GraphOptions: NewGraphOptions(parent, flags, streams),
+	}
+}
+
+// NewCmdDiff creates a command object for the "graph diff" action.
+func NewCmdDiff(parent string, flags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	f := cmdutil.NewFactory(flags)
+	o := NewDiffOptions(parent, flags, streams)
+
+	cmd := &cobra.Command{
+		Use:                   "diff (--from-snapshot FILE | --from-filename FILENAME | --from-context CONTEXT) [(-o|--output=)text|json|aql|arangodb|cql|cypher|dot|graphviz|mermaid] (TYPE[.VERSION][.GROUP] ... | --to-snapshot FILE) [flags]",
+		DisableFlagsInUseLine: true,
+		Short:                 "Compare the graph of one or many resources with a base graph",
+		Long:                  diffLong,
+		Example:               fmt.Sprintf(diffExample, parent),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate(cmd, args))
+			cmdutil.CheckErr(o.Run(f, cmd, args))
+		},
+	}
+
+	cmd.Flags().BoolP("help", "h", false, fmt.Sprintf("Help for %s graph diff", parent))
+	cmd.Flags().StringVar(&o.BaseSnapshot, "from-snapshot", o.BaseSnapshot, "Snapshot file written with -o json, which is the base graph. Use - to read from stdin.")
+	cmd.Flags().StringSliceVar(&o.BaseFilenames, "from-filename", o.BaseFilenames, "Files or directories with manifests, which are resolved locally to the base graph.")
+	cmd.Flags().StringVar(&o.BaseContext, "from-context", o.BaseContext, "Kubeconfig context of the cluster, whose resources are resolved to the base graph.")
+	cmd.Flags().StringVar(&o.FromSnapshot, "to-snapshot", o.FromSnapshot, "If present, the graph is read from a snapshot file instead of resolving resources. Use - to read from stdin.")
+	cmd.Flags().StringVarP(&o.OutputFormat, "output", "o", o.OutputFormat, "Output format. One of: text|json|aql|arangodb|cql|cypher|dot|graphviz|mermaid.")
+	o.AddResourceFlags(cmd)
+
+	return cmd
+}
+
+// Complete takes the command arguments and factory and infers any remaining options.
+func (o *DiffOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if o.OutputFormat == "" {
+		o.OutputFormat = "text"
+	}
+
+	return o.GraphOptions.Complete(f, cmd, args)
+}
+
+// Validate checks the set of flags provided by the user.
+func (o *DiffOptions) Validate(cmd *cobra.Command, args []string) error {
+	bases := 0
+	for _, set := range []bool{o.BaseSnapshot != "", len(o.BaseFilenames) != 0, o.BaseContext != ""} {
+		if set {
+			bases++
+		}
+	}
+	if bases != 1 {
+		return fmt.Errorf("you must specify the base graph by exactly one of --from-snapshot, --from-filename or --from-context")
+	}
+	if o.BaseContext != "" && o.FromSnapshot != "" {
+		return fmt.Errorf("--from-context can not be combined with --to-snapshot, the same resources are resolved in both contexts")
+	}
+	if o.BaseSnapshot == "-" && o.FromSnapshot == "-" {
+		return fmt.Errorf("only one snapshot can be read from stdin")
+	}
+	if err := o.ValidateResources(args); err != nil {
+		return err
+	}
+	if !(o.OutputFormat == "text" || o.OutputFormat == "json" || o.OutputFormat == "arangodb" || o.OutputFormat == "cypher" || o.OutputFormat == "graphviz" || o.OutputFormat == "mermaid") {
+		return fmt.Errorf("invalid output format: %q, allowed formats are: %s", o.OutputFormat, "text|json|aql|arangodb|cql|cypher|dot|graphviz|mermaid")
+	}
+
+	return nil
+}
+
+// Run performs the graph diff operation. Incomplete graphs are not compared.
+func (o *DiffOptions) Run(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	ctx, cancel := o.Context()
+	defer cancel()
+
+	base, baseFactory, baseArgs := o.Base(f, args)
+	from, err := base.Graph(ctx, baseFactory, baseArgs)
+	if err != nil {
+		return err
+	}
+	defer from.WriteWarnings(o.ErrOut)
+
+	to, err := o.Graph(ctx, f, args)
+	if err != nil {
+		return err
+	}
+	defer to.WriteWarnings(o.ErrOut)
+
+	combined, report := graph.Diff(from, to)
+	if o.OutputFormat == "text" || o.OutputFormat == "json" {
+		return report.Write(o.Out, o.OutputFormat)
+	}
+
+	return combined.Write(o.Out, o.OutputFormat)
+}
+
+// Base returns the options, the factory and the arguments to resolve the base graph. The base graph of
+// another context is resolved from the same resources and namespaces.
+func (o *DiffOptions) Base(f cmdutil.Factory, args []string) (*GraphOptions, cmdutil.Factory, []string) {
+	base := *o.GraphOptions
+	base.FromSnapshot = o.BaseSnapshot
+
+	switch {
+	case o.BaseSnapshot != "":
+		return &base, f, nil
+	case len(o.BaseFilenames) != 0:
+		base.Local = true
+		base.FilenameOptions = resource.FilenameOptions{Filenames: o.BaseFilenames, Recursive: o.Recursive}
+		return &base, f, nil
+	}
+
+	flags := genericclioptions.NewConfigFlags(true)
+	flags.KubeConfig = o.configFlags.KubeConfig
+	flags.Timeout = o.configFlags.Timeout
+	flags.Context = &o.BaseContext
+
+	base.Local = false
+	base.configFlags = flags
+
+	return &base, cmdutil.NewFactory(flags), args
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"strings"
+
+	v1 "github.com/openshift/api/apps/v1"
+)
+
+func init() {
+	Register(v1.SchemeGroupVersion.WithKind("DeploymentConfig"), Typed((*Graph).AppsV1, (*AppsV1Graph).DeploymentConfig))
+}
+
+// AppsV1Graph is used to graph all OpenShift apps resources.
+type AppsV1Graph struct {
+	graph *Graph
+}
+
+// NewAppsV1Graph creates a new AppsV1Graph.
+func NewAppsV1Graph(g *Graph) *AppsV1Graph {
+	return &AppsV1Graph{
+		graph: g,
+	}
+}
+
+// AppsV1 retrieves the AppsV1Graph.
+func (g *Graph) AppsV1() *AppsV1Graph {
+	return g.appsV1
+}
+
+// DeploymentConfig adds a v1.DeploymentConfig resource to the Graph.
+func (g *AppsV1Graph) DeploymentConfig(obj *v1.DeploymentConfig) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	for _, trigger := range obj.Spec.Triggers {
+		if trigger.Type != v1.DeploymentTriggerOnImageChange || trigger.ImageChangeParams == nil {
+			continue
+		}
+
+		image, err := g.graph.ImageV1().ObjectReference(&trigger.ImageChangeParams.From, obj.GetNamespace())
+		if err != nil {
+			return nil, err
+		}
+		if image == nil {
+			continue
+		}
+
+		r := g.graph.Relationship(n, "ImageChange", image)
+		if len(trigger.ImageChangeParams.ContainerNames) != 0 {
+			r.Attribute("containers", strings.Join(trigger.ImageChangeParams.ContainerNames, ", "))
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"github.com/steveteuber/kubectl-graph/pkg/apis/bitnami/v1alpha1"
+)
+
+func init() {
+	Register(v1alpha1.SchemeGroupVersion.WithKind("SealedSecret"), Typed((*Graph).BitnamiV1alpha1, (*BitnamiV1alpha1Graph).SealedSecret))
+}
+
+// BitnamiV1alpha1Graph is used to graph all Sealed Secrets resources.
+type BitnamiV1alpha1Graph struct {
+	graph *Graph
+}
+
+// NewBitnamiV1alpha1Graph creates a new BitnamiV1alpha1Graph.
+func NewBitnamiV1alpha1Graph(g *Graph) *BitnamiV1alpha1Graph {
+	return &BitnamiV1alpha1Graph{
+		graph: g,
+	}
+}
+
+// BitnamiV1alpha1 retrieves the BitnamiV1alpha1Graph.
+func (g *Graph) BitnamiV1alpha1() *BitnamiV1alpha1Graph {
+	return g.bitnamiV1alpha1
+}
+
+// SealedSecret adds a v1alpha1.SealedSecret resource to the Graph.
+func (g *BitnamiV1alpha1Graph) SealedSecret(obj *v1alpha1.SealedSecret) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	name := obj.Spec.Template.GetName()
+	if name == "" {
+		name = obj.GetName()
+	}
+
+	s, err := g.graph.CoreV1().Secret(obj.GetNamespace(), name)
+	if err != nil {
+		return nil, err
+	}
+	g.graph.Relationship(n, "Secret", s)
+
+	return n, nil
+}
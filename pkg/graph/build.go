@@ -0,0 +1,126 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	v1 "github.com/openshift/api/build/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	Register(v1.SchemeGroupVersion.WithKind("BuildConfig"), Typed((*Graph).BuildV1, (*BuildV1Graph).BuildConfig))
+	Register(v1.SchemeGroupVersion.WithKind("Build"), Typed((*Graph).BuildV1, (*BuildV1Graph).Build))
+}
+
+// BuildV1Graph is used to graph all OpenShift build resources.
+type BuildV1Graph struct {
+	graph *Graph
+}
+
+// NewBuildV1Graph creates a new BuildV1Graph.
+func NewBuildV1Graph(g *Graph) *BuildV1Graph {
+	return &BuildV1Graph{
+		graph: g,
+	}
+}
+
+// BuildV1 retrieves the BuildV1Graph.
+func (g *Graph) BuildV1() *BuildV1Graph {
+	return g.buildV1
+}
+
+// BuildConfig adds a v1.BuildConfig resource to the Graph.
+func (g *BuildV1Graph) BuildConfig(obj *v1.BuildConfig) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if err := g.CommonSpec(n, obj.GetNamespace(), obj.Spec.CommonSpec); err != nil {
+		return nil, err
+	}
+
+	for _, trigger := range obj.Spec.Triggers {
+		if trigger.ImageChange == nil || trigger.ImageChange.From == nil {
+			continue
+		}
+
+		image, err := g.graph.ImageV1().ObjectReference(trigger.ImageChange.From, obj.GetNamespace())
+		if err != nil {
+			return nil, err
+		}
+		if image != nil {
+			g.graph.Relationship(n, "ImageChange", image)
+		}
+	}
+
+	return n, nil
+}
+
+// Build adds a v1.Build resource to the Graph.
+func (g *BuildV1Graph) Build(obj *v1.Build) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.CommonSpec(n, obj.GetNamespace(), obj.Spec.CommonSpec)
+}
+
+// CommonSpec adds the source secret, base image and output image of a build to the Graph.
+func (g *BuildV1Graph) CommonSpec(n *Node, namespace string, spec v1.CommonSpec) error {
+	secrets := []*corev1.LocalObjectReference{spec.Source.SourceSecret, spec.Output.PushSecret}
+
+	var from *corev1.ObjectReference
+	switch {
+	case spec.Strategy.SourceStrategy != nil:
+		from = &spec.Strategy.SourceStrategy.From
+		secrets = append(secrets, spec.Strategy.SourceStrategy.PullSecret)
+	case spec.Strategy.DockerStrategy != nil:
+		from = spec.Strategy.DockerStrategy.From
+		secrets = append(secrets, spec.Strategy.DockerStrategy.PullSecret)
+	case spec.Strategy.CustomStrategy != nil:
+		from = &spec.Strategy.CustomStrategy.From
+		secrets = append(secrets, spec.Strategy.CustomStrategy.PullSecret)
+	}
+
+	if from != nil {
+		image, err := g.graph.ImageV1().ObjectReference(from, namespace)
+		if err != nil {
+			return err
+		}
+		if image != nil {
+			g.graph.Relationship(n, "From", image)
+		}
+	}
+
+	if spec.Output.To != nil {
+		image, err := g.graph.ImageV1().ObjectReference(spec.Output.To, namespace)
+		if err != nil {
+			return err
+		}
+		if image != nil {
+			g.graph.Relationship(n, "Output", image)
+		}
+	}
+
+	for _, secret := range secrets {
+		if secret == nil || secret.Name == "" {
+			continue
+		}
+
+		s, err := g.graph.CoreV1().Secret(namespace, secret.Name)
+		if err != nil {
+			return err
+		}
+		g.graph.Relationship(n, "Secret", s)
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	v3 "github.com/steveteuber/kubectl-graph/pkg/apis/calico/v3"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+var (
+	calicoSelectorTerm = regexp.MustCompile(`^([A-Za-z0-9./_-]+)\s*(==|!=|not in|in)\s*(.+)$`)
+	calicoSelectorHas  = regexp.MustCompile(`^(!?)\s*has\(\s*([A-Za-z0-9./_-]+)\s*\)$`)
+)
+
+func init() {
+	Register(v3.SchemeGroupVersion.WithKind("NetworkPolicy"), Typed((*Graph).CalicoV3, (*CalicoV3Graph).NetworkPolicy))
+	Register(v3.SchemeGroupVersion.WithKind("GlobalNetworkPolicy"), Typed((*Graph).CalicoV3, (*CalicoV3Graph).GlobalNetworkPolicy))
+
+	Register(v3.CRDGroupVersion.WithKind("NetworkPolicy"), Typed((*Graph).CalicoV3, (*CalicoV3Graph).NetworkPolicy))
+	Register(v3.CRDGroupVersion.WithKind("GlobalNetworkPolicy"), Typed((*Graph).CalicoV3, (*CalicoV3Graph).GlobalNetworkPolicy))
+
+	for _, gv := range []schema.GroupVersion{v3.SchemeGroupVersion, v3.CRDGroupVersion} {
+		for _, kind := range []string{"NetworkPolicy", "GlobalNetworkPolicy"} {
+			Depends(gv.WithKind(kind), corev1.SchemeGroupVersion.WithKind("Pod"), corev1.SchemeGroupVersion.WithKind("Namespace"))
+		}
+	}
+}
+
+// CalicoV3Graph is used to graph all Calico resources.
+type CalicoV3Graph struct {
+	graph *Graph
+}
+
+// NewCalicoV3Graph creates a new CalicoV3Graph.
+func NewCalicoV3Graph(g *Graph) *CalicoV3Graph {
+	return &CalicoV3Graph{
+		graph: g,
+	}
+}
+
+// CalicoV3 retrieves the CalicoV3Graph.
+func (g *Graph) CalicoV3() *CalicoV3Graph {
+	return g.calicoV3
+}
+
+// NetworkPolicy adds a v3.NetworkPolicy resource to the Graph.
+func (g *CalicoV3Graph) NetworkPolicy(obj *v3.NetworkPolicy) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.Spec(n, obj.GetNamespace(), obj.Spec)
+}
+
+// GlobalNetworkPolicy adds a v3.GlobalNetworkPolicy resource to the Graph.
+func (g *CalicoV3Graph) GlobalNetworkPolicy(obj *v3.GlobalNetworkPolicy) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.Spec(n, metav1.NamespaceAll, obj.Spec)
+}
+
+// Spec adds the selected endpoints and peers of a v3.NetworkPolicySpec to the Graph.
+// An empty namespace is used for global policies.
+func (g *CalicoV3Graph) Spec(n *Node, namespace string, spec v3.NetworkPolicySpec) error {
+	types := spec.Types
+	if len(types) == 0 {
+		types = append(types, string(networkingv1.PolicyTypeIngress))
+		if len(spec.Egress) != 0 {
+			types = append(types, string(networkingv1.PolicyTypeEgress))
+		}
+	}
+
+	endpoints, err := g.Endpoints(namespace, spec.NamespaceSelector, spec.Selector)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		for _, policyType := range types {
+			g.graph.NetworkingV1().Relationship(endpoint, networkingv1.PolicyType(policyType), n)
+		}
+	}
+
+	for _, rule := range spec.Ingress {
+		if err := g.Rule(n, namespace, networkingv1.PolicyTypeIngress, rule, rule.Source); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range spec.Egress {
+		if err := g.Rule(n, namespace, networkingv1.PolicyTypeEgress, rule, rule.Destination); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rule adds the peers of a v3.EntityRule to the Graph. The peer is the source of ingress and the destination of egress rules.
+// An entity without selectors, nets and domains matches any endpoint, even for namespaced policies.
+func (g *CalicoV3Graph) Rule(n *Node, namespace string, policyType networkingv1.PolicyType, rule v3.Rule, entity v3.EntityRule) error {
+	networking := g.graph.NetworkingV1()
+	peers := []*Node{}
+
+	switch {
+	case entity.Selector != "" || entity.NamespaceSelector != "":
+		pods, err := g.Endpoints(namespace, entity.NamespaceSelector, entity.Selector)
+		if err != nil {
+			return err
+		}
+		peers = append(peers, pods...)
+	case len(entity.Nets) == 0 && len(entity.Domains) == 0:
+		// An entity without selectors, nets and domains matches any endpoint.
+		all, err := networking.Any()
+		if err != nil {
+			return err
+		}
+		peers = append(peers, all)
+	}
+
+	for _, cidr := range entity.Nets {
+		i, err := networking.IPBlock(cidr)
+		if err != nil {
+			return err
+		}
+		peers = append(peers, i)
+	}
+
+	for _, domain := range entity.Domains {
+		f, err := networking.FQDN(domain)
+		if err != nil {
+			return err
+		}
+		peers = append(peers, f)
+	}
+
+	action := rule.Action
+	if action == "" {
+		action = "Allow"
+	}
+
+	for _, peer := range peers {
+		networking.Rule(n, policyType, peer, action, entity.NotNets, CalicoPorts(rule))
+	}
+
+	return nil
+}
+
+// CalicoPorts formats the protocol and destination ports of a v3.Rule as protocol/port strings.
+func CalicoPorts(rule v3.Rule) []string {
+	formatted := []string{}
+
+	protocol := "ANY"
+	if rule.Protocol != nil {
+		protocol = strings.ToUpper(rule.Protocol.String())
+	}
+
+	for _, port := range rule.Destination.Ports {
+		formatted = append(formatted, fmt.Sprintf("%s/%s", protocol, strings.Replace(port.String(), ":", "-", 1)))
+	}
+
+	if len(formatted) == 0 && rule.Protocol != nil {
+		formatted = append(formatted, fmt.Sprintf("%s/*", protocol))
+	}
+
+	return formatted
+}
+
+// Endpoints adds all pods matching a Calico namespace selector and selector to the Graph.
+func (g *CalicoV3Graph) Endpoints(namespace string, namespaceSelector string, selector string) ([]*Node, error) {
+	pods, err := CalicoSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaces labels.Selector
+	if namespaceSelector != "" {
+		namespaces, err = CalicoSelector(namespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return g.graph.NetworkingV1().SelectPods(namespace, namespaces, pods)
+}
+
+// CalicoSelector converts a Calico selector expression into a label selector.
+//
+// Only conjunctions of equality, set based and has() expressions are supported,
+// which covers the selectors generated from Kubernetes label selectors.
+func CalicoSelector(expression string) (labels.Selector, error) {
+	selector := labels.NewSelector()
+
+	expression = strings.TrimSpace(expression)
+	if expression == "" || expression == "all()" {
+		return selector, nil
+	}
+
+	if strings.Contains(expression, "||") {
+		return nil, fmt.Errorf("calico selector %q is not supported yet", expression)
+	}
+
+	for _, term := range strings.Split(expression, "&&") {
+		term = strings.TrimSpace(term)
+
+		var requirement *labels.Requirement
+		var err error
+
+		if match := calicoSelectorHas.FindStringSubmatch(term); match != nil {
+			operator := selection.Exists
+			if match[1] == "!" {
+				operator = selection.DoesNotExist
+			}
+			requirement, err = labels.NewRequirement(match[2], operator, nil)
+		} else if match := calicoSelectorTerm.FindStringSubmatch(term); match != nil {
+			operators := map[string]selection.Operator{
+				"==":     selection.Equals,
+				"!=":     selection.NotEquals,
+				"in":     selection.In,
+				"not in": selection.NotIn,
+			}
+			requirement, err = labels.NewRequirement(match[1], operators[match[2]], CalicoSelectorValues(match[3]))
+		} else {
+			return nil, fmt.Errorf("calico selector %q is not supported yet", expression)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*requirement)
+	}
+
+	return selector, nil
+}
+
+// CalicoSelectorValues converts a quoted value or a set of quoted values into a list of strings.
+func CalicoSelectorValues(s string) []string {
+	values := []string{}
+
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	for _, value := range strings.Split(s, ",") {
+		values = append(values, strings.Trim(strings.TrimSpace(value), `'"`))
+	}
+
+	return values
+}
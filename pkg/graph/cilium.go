@@ -0,0 +1,337 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	v2 "github.com/steveteuber/kubectl-graph/pkg/apis/cilium/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// ciliumPodNamespaceLabel is the endpoint label which contains the namespace of a pod.
+	ciliumPodNamespaceLabel = "io.kubernetes.pod.namespace"
+	// ciliumNamespaceLabelPrefix is the endpoint label prefix which contains the labels of the namespace of a pod.
+	ciliumNamespaceLabelPrefix = "io.cilium.k8s.namespace.labels."
+)
+
+func init() {
+	Register(v2.SchemeGroupVersion.WithKind("CiliumNetworkPolicy"), Typed((*Graph).CiliumV2, (*CiliumV2Graph).CiliumNetworkPolicy))
+	Register(v2.SchemeGroupVersion.WithKind("CiliumClusterwideNetworkPolicy"), Typed((*Graph).CiliumV2, (*CiliumV2Graph).CiliumClusterwideNetworkPolicy))
+
+	for _, kind := range []string{"CiliumNetworkPolicy", "CiliumClusterwideNetworkPolicy"} {
+		Depends(v2.SchemeGroupVersion.WithKind(kind), corev1.SchemeGroupVersion.WithKind("Pod"), corev1.SchemeGroupVersion.WithKind("Namespace"), corev1.SchemeGroupVersion.WithKind("Node"))
+	}
+}
+
+// CiliumV2Graph is used to graph all Cilium resources.
+type CiliumV2Graph struct {
+	graph *Graph
+}
+
+// NewCiliumV2Graph creates a new CiliumV2Graph.
+func NewCiliumV2Graph(g *Graph) *CiliumV2Graph {
+	return &CiliumV2Graph{
+		graph: g,
+	}
+}
+
+// CiliumV2 retrieves the CiliumV2Graph.
+func (g *Graph) CiliumV2() *CiliumV2Graph {
+	return g.ciliumV2
+}
+
+// CiliumNetworkPolicy adds a v2.CiliumNetworkPolicy resource to the Graph.
+func (g *CiliumV2Graph) CiliumNetworkPolicy(obj *v2.CiliumNetworkPolicy) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	rules := obj.Specs
+	if obj.Spec != nil {
+		rules = append(rules, *obj.Spec)
+	}
+
+	for _, rule := range rules {
+		if err := g.Rule(n, obj.GetNamespace(), rule); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// CiliumClusterwideNetworkPolicy adds a v2.CiliumClusterwideNetworkPolicy resource to the Graph.
+func (g *CiliumV2Graph) CiliumClusterwideNetworkPolicy(obj *v2.CiliumClusterwideNetworkPolicy) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	rules := obj.Specs
+	if obj.Spec != nil {
+		rules = append(rules, *obj.Spec)
+	}
+
+	for _, rule := range rules {
+		if err := g.Rule(n, metav1.NamespaceAll, rule); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// Rule adds the endpoints and peers of a v2.Rule to the Graph. An empty namespace is used for cluster wide policies.
+func (g *CiliumV2Graph) Rule(n *Node, namespace string, rule v2.Rule) error {
+	networking := g.graph.NetworkingV1()
+
+	if rule.EndpointSelector != nil {
+		endpoints, err := g.Endpoints(namespace, *rule.EndpointSelector)
+		if err != nil {
+			return err
+		}
+
+		for _, endpoint := range endpoints {
+			if len(rule.Ingress) != 0 || len(rule.IngressDeny) != 0 {
+				networking.Relationship(endpoint, networkingv1.PolicyTypeIngress, n)
+			}
+			if len(rule.Egress) != 0 || len(rule.EgressDeny) != 0 {
+				networking.Relationship(endpoint, networkingv1.PolicyTypeEgress, n)
+			}
+		}
+	}
+
+	if rule.NodeSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(rule.NodeSelector)
+		if err != nil {
+			return err
+		}
+
+		nodes, err := networking.SelectNodes(selector)
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			if len(rule.Ingress) != 0 || len(rule.IngressDeny) != 0 {
+				networking.Relationship(node, networkingv1.PolicyTypeIngress, n)
+			}
+			if len(rule.Egress) != 0 || len(rule.EgressDeny) != 0 {
+				networking.Relationship(node, networkingv1.PolicyTypeEgress, n)
+			}
+		}
+	}
+
+	for _, ingress := range rule.Ingress {
+		if err := g.IngressRule(n, namespace, "Allow", ingress); err != nil {
+			return err
+		}
+	}
+
+	for _, ingress := range rule.IngressDeny {
+		if err := g.IngressRule(n, namespace, "Deny", ingress); err != nil {
+			return err
+		}
+	}
+
+	for _, egress := range rule.Egress {
+		if err := g.EgressRule(n, namespace, "Allow", egress); err != nil {
+			return err
+		}
+	}
+
+	for _, egress := range rule.EgressDeny {
+		if err := g.EgressRule(n, namespace, "Deny", egress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IngressRule adds the peers of a v2.IngressRule to the Graph.
+func (g *CiliumV2Graph) IngressRule(n *Node, namespace string, action string, rule v2.IngressRule) error {
+	return g.Peers(n, networkingv1.PolicyTypeIngress, action, namespace, rule.FromEndpoints, rule.FromCIDR, rule.FromCIDRSet, rule.FromEntities, nil, rule.ToPorts)
+}
+
+// EgressRule adds the peers of a v2.EgressRule to the Graph.
+func (g *CiliumV2Graph) EgressRule(n *Node, namespace string, action string, rule v2.EgressRule) error {
+	return g.Peers(n, networkingv1.PolicyTypeEgress, action, namespace, rule.ToEndpoints, rule.ToCIDR, rule.ToCIDRSet, rule.ToEntities, rule.ToFQDNs, rule.ToPorts)
+}
+
+// Peers adds all endpoints, CIDRs, entities and FQDNs of a rule and their relationships to the policy to the Graph.
+// A rule without any peers, like a rule with only ports, applies to all peers and is added for the entity all.
+func (g *CiliumV2Graph) Peers(n *Node, policyType networkingv1.PolicyType, action string, namespace string, endpoints []metav1.LabelSelector, cidrs []string, cidrSets []v2.CIDRRule, entities []string, fqdns []v2.FQDNSelector, ports []v2.PortRule) error {
+	networking := g.graph.NetworkingV1()
+
+	relationship := func(peer *Node, except []string) *Relationship {
+		return networking.Rule(n, policyType, peer, action, except, CiliumPorts(ports))
+	}
+
+	if len(endpoints) == 0 && len(cidrs) == 0 && len(cidrSets) == 0 && len(entities) == 0 && len(fqdns) == 0 {
+		entities = []string{"all"}
+	}
+
+	for _, selector := range endpoints {
+		if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+			peer, err := g.AllEndpoints(namespace)
+			if err != nil {
+				return err
+			}
+			relationship(peer, nil)
+			continue
+		}
+
+		pods, err := g.Endpoints(namespace, selector)
+		if err != nil {
+			return err
+		}
+
+		for _, pod := range pods {
+			relationship(pod, nil)
+		}
+	}
+
+	for _, cidr := range cidrs {
+		cidrSets = append(cidrSets, v2.CIDRRule{Cidr: cidr})
+	}
+
+	for _, cidrSet := range cidrSets {
+		i, err := networking.IPBlock(cidrSet.Cidr)
+		if err != nil {
+			return err
+		}
+
+		relationship(i, cidrSet.ExceptCIDRs)
+	}
+
+	for _, entity := range entities {
+		e, err := networking.Entity(entity)
+		if err != nil {
+			return err
+		}
+		relationship(e, nil)
+	}
+
+	for _, fqdn := range fqdns {
+		name := fqdn.MatchName
+		if name == "" {
+			name = fqdn.MatchPattern
+		}
+
+		f, err := networking.FQDN(name)
+		if err != nil {
+			return err
+		}
+		relationship(f, nil)
+	}
+
+	return nil
+}
+
+// CiliumPorts formats a list of v2.PortRule as protocol/port strings.
+func CiliumPorts(rules []v2.PortRule) []string {
+	formatted := []string{}
+
+	for _, rule := range rules {
+		for _, port := range rule.Ports {
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = "ANY"
+			}
+
+			switch {
+			case port.Port == "" || port.Port == "0":
+				formatted = append(formatted, fmt.Sprintf("%s/*", protocol))
+			case port.EndPort != 0:
+				formatted = append(formatted, fmt.Sprintf("%s/%s-%d", protocol, port.Port, port.EndPort))
+			default:
+				formatted = append(formatted, fmt.Sprintf("%s/%s", protocol, port.Port))
+			}
+		}
+	}
+
+	return formatted
+}
+
+// Endpoints adds all pods matching a Cilium endpoint selector to the Graph.
+//
+// Cilium endpoint selectors may select the namespace of a pod with special labels,
+// otherwise namespaced policies only select pods within their own namespace.
+func (g *CiliumV2Graph) Endpoints(namespace string, endpointSelector metav1.LabelSelector) ([]*Node, error) {
+	podSelector := &metav1.LabelSelector{MatchLabels: map[string]string{}}
+	namespaceSelector := &metav1.LabelSelector{MatchLabels: map[string]string{}}
+
+	for key, value := range endpointSelector.MatchLabels {
+		key = CiliumLabelKey(key)
+		switch {
+		case key == ciliumPodNamespaceLabel:
+			namespace = value
+		case strings.HasPrefix(key, ciliumNamespaceLabelPrefix):
+			namespaceSelector.MatchLabels[strings.TrimPrefix(key, ciliumNamespaceLabelPrefix)] = value
+		default:
+			podSelector.MatchLabels[key] = value
+		}
+	}
+
+	for _, expression := range endpointSelector.MatchExpressions {
+		expression.Key = CiliumLabelKey(expression.Key)
+		switch {
+		case expression.Key == ciliumPodNamespaceLabel:
+			expression.Key = "kubernetes.io/metadata.name"
+			namespaceSelector.MatchExpressions = append(namespaceSelector.MatchExpressions, expression)
+		case strings.HasPrefix(expression.Key, ciliumNamespaceLabelPrefix):
+			expression.Key = strings.TrimPrefix(expression.Key, ciliumNamespaceLabelPrefix)
+			namespaceSelector.MatchExpressions = append(namespaceSelector.MatchExpressions, expression)
+		default:
+			podSelector.MatchExpressions = append(podSelector.MatchExpressions, expression)
+		}
+	}
+
+	pods, err := metav1.LabelSelectorAsSelector(podSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaces labels.Selector
+	if len(namespaceSelector.MatchLabels) != 0 || len(namespaceSelector.MatchExpressions) != 0 {
+		namespaces, err = metav1.LabelSelectorAsSelector(namespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return g.graph.NetworkingV1().SelectPods(namespace, namespaces, pods)
+}
+
+// AllEndpoints adds the peer of an empty endpoint selector to the Graph. It selects all endpoints in the namespace
+// of a policy, so the namespace is the peer, or the entity cluster for cluster wide policies.
+func (g *CiliumV2Graph) AllEndpoints(namespace string) (*Node, error) {
+	if namespace == metav1.NamespaceAll {
+		return g.graph.NetworkingV1().Entity("cluster")
+	}
+
+	return g.graph.CoreV1().Namespace(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+}
+
+// CiliumLabelKey removes the source prefix like "k8s:" or "any:" from a Cilium label key.
+func CiliumLabelKey(key string) string {
+	for _, prefix := range []string{"k8s:", "any:"} {
+		key = strings.TrimPrefix(key, prefix)
+	}
+
+	return key
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCiliumAllPeers(t *testing.T) {
+	objs := testObjects(t, `
+apiVersion: cilium.io/v2
+kind: CiliumNetworkPolicy
+metadata:
+  name: web
+  namespace: shop
+  uid: cnp-web
+spec:
+  endpointSelector:
+    matchLabels:
+      app: web
+  ingress:
+    - fromEndpoints:
+        - {}
+    - toPorts:
+        - ports:
+            - port: "443"
+              protocol: TCP
+  egressDeny:
+    - toPorts:
+        - ports:
+            - port: "25"
+              protocol: TCP
+---
+apiVersion: cilium.io/v2
+kind: CiliumClusterwideNetworkPolicy
+metadata:
+  name: monitoring
+  uid: ccnp-monitoring
+spec:
+  endpointSelector: {}
+  ingress:
+    - fromEndpoints:
+        - {}
+      toPorts:
+        - ports:
+            - port: "9090"
+              protocol: TCP
+`)
+
+	g, err := NewGraph(context.Background(), nil, objs, &Options{Concurrency: 1}, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"CiliumNetworkPolicy/web Egress Entity/all action=Deny ports=TCP/25",
+		"Cluster/local Entity Entity/cluster",
+		"Cluster/local Namespace Namespace/shop",
+		"Entity/all Ingress CiliumNetworkPolicy/web action=Allow ports=TCP/443",
+		"Entity/cluster Ingress CiliumClusterwideNetworkPolicy/monitoring action=Allow ports=TCP/9090",
+		"Namespace/shop Ingress CiliumNetworkPolicy/web action=Allow ports=*",
+	}
+	if got := testEdges(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("relationships = %q, want %q", got, want)
+	}
+}
@@ -15,16 +15,33 @@
 package graph
 
 import (
-	"context"
 	"fmt"
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// podSecurityLabelPrefix is the prefix of the namespace labels which configure the Pod Security admission.
+const podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+
+func init() {
+	Register(v1.SchemeGroupVersion.WithKind("Namespace"), Typed((*Graph).CoreV1, (*CoreV1Graph).Namespace))
+	Register(v1.SchemeGroupVersion.WithKind("ResourceQuota"), Typed((*Graph).CoreV1, (*CoreV1Graph).ResourceQuota))
+	Register(v1.SchemeGroupVersion.WithKind("LimitRange"), Typed((*Graph).CoreV1, (*CoreV1Graph).LimitRange))
+	Register(v1.SchemeGroupVersion.WithKind("Pod"), Typed((*Graph).CoreV1, (*CoreV1Graph).Pod))
+	Register(v1.SchemeGroupVersion.WithKind("Endpoints"), Typed((*Graph).CoreV1, (*CoreV1Graph).Endpoints))
+	Register(v1.SchemeGroupVersion.WithKind("PersistentVolume"), Typed((*Graph).CoreV1, (*CoreV1Graph).PersistentVolume))
+	Register(v1.SchemeGroupVersion.WithKind("PersistentVolumeClaim"), Typed((*Graph).CoreV1, (*CoreV1Graph).PersistentVolumeClaim))
+	Register(v1.SchemeGroupVersion.WithKind("Service"), Typed((*Graph).CoreV1, (*CoreV1Graph).Service))
+	Register(v1.SchemeGroupVersion.WithKind("Node"), Typed((*Graph).CoreV1, (*CoreV1Graph).Node))
+
+	Depends(v1.SchemeGroupVersion.WithKind("Service"), v1.SchemeGroupVersion.WithKind("Endpoints"))
+}
+
 // CoreV1Graph is used to graph all core resources.
 type CoreV1Graph struct {
 	graph *Graph
@@ -42,64 +59,15 @@ func (g *Graph) CoreV1() *CoreV1Graph {
 	return g.coreV1
 }
 
-// Unstructured adds an unstructured node to the Graph.
-func (g *CoreV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
-	switch unstr.GetKind() {
-	case "Namespace":
-		obj := &v1.Namespace{}
-		if err := FromUnstructured(unstr, obj); err != nil {
-			return nil, err
-		}
-		return g.Namespace(obj)
-	case "Pod":
-		obj := &v1.Pod{}
-		if err := FromUnstructured(unstr, obj); err != nil {
-			return nil, err
-		}
-		return g.Pod(obj)
-	case "Endpoints":
-		obj := &v1.Endpoints{}
-		if err := FromUnstructured(unstr, obj); err != nil {
-			return nil, err
-		}
-		return g.Endpoints(obj)
-	case "PersistentVolume":
-		obj := &v1.PersistentVolume{}
-		if err := FromUnstructured(unstr, obj); err != nil {
-			return nil, err
-		}
-		return g.PersistentVolume(obj)
-	case "PersistentVolumeClaim":
-		obj := &v1.PersistentVolumeClaim{}
-		if err := FromUnstructured(unstr, obj); err != nil {
-			return nil, err
-		}
-		return g.PersistentVolumeClaim(obj)
-	case "Service":
-		obj := &v1.Service{}
-		if err := FromUnstructured(unstr, obj); err != nil {
-			return nil, err
-		}
-		return g.Service(obj)
-	case "Node":
-		obj := &v1.Node{}
-		if err := FromUnstructured(unstr, obj); err != nil {
-			return nil, err
-		}
-		return g.Node(obj)
-	default:
-		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
-	}
-}
-
 // Cluster adds a v1.Cluster resource to the Graph.
 func (g *CoreV1Graph) Cluster() (*Node, error) {
-	c := g.graph.clientset.RESTClient().Get().URL().Hostname()
+	c := g.graph.cluster
 
+	gvk := schema.FromAPIVersionAndKind(v1.GroupName, "Cluster")
 	n := g.graph.Node(
-		schema.FromAPIVersionAndKind(v1.GroupName, "Cluster"),
+		gvk,
 		&metav1.ObjectMeta{
-			UID:  ToUID("Cluster", c),
+			UID:  g.graph.SyntheticUID(gvk, "", c),
 			Name: c,
 		},
 	)
@@ -107,22 +75,78 @@ func (g *CoreV1Graph) Cluster() (*Node, error) {
 	return n, nil
 }
 
-// Namespace adds a v1.Namespace resource to the Graph.
+// Namespace adds a v1.Namespace resource to the Graph or resolves an existing node for it.
 func (g *CoreV1Graph) Namespace(ns *v1.Namespace) (*Node, error) {
 	c, err := g.Cluster()
 	if err != nil {
 		return nil, err
 	}
 
-	n := g.graph.Node(
-		schema.FromAPIVersionAndKind(v1.GroupName, "Namespace"),
-		&metav1.ObjectMeta{
-			UID:  ToUID(c.GetName(), ns.GetName()),
-			Name: ns.GetName(),
-		},
-	)
+	obj := ns.DeepCopy()
+	if n := g.graph.FindNode(v1.SchemeGroupVersion.String(), "Namespace", "", ns.GetName()); n != nil {
+		obj.SetUID(n.GetUID())
+	}
+	if obj.GetUID() == "" {
+		obj.SetUID(g.graph.SyntheticUID(v1.SchemeGroupVersion.WithKind("Namespace"), "", ns.GetName()))
+	}
+
+	n := g.graph.Node(v1.SchemeGroupVersion.WithKind("Namespace"), obj)
 	g.graph.Relationship(c, "Namespace", n)
 
+	for _, mode := range []string{"enforce", "audit", "warn"} {
+		level, ok := ns.GetLabels()[podSecurityLabelPrefix+mode]
+		if !ok {
+			continue
+		}
+		if version := ns.GetLabels()[podSecurityLabelPrefix+mode+"-version"]; version != "" && version != "latest" {
+			level += ":" + version
+		}
+		n.Property("podSecurity."+mode, level)
+	}
+
+	return n, nil
+}
+
+// ResourceQuota adds a v1.ResourceQuota resource with its hard limits to the Graph. Its usage is part of the status.
+func (g *CoreV1Graph) ResourceQuota(obj *v1.ResourceQuota) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	for name, quantity := range obj.Spec.Hard {
+		n.Property("hard."+string(name), quantity.String())
+	}
+
+	ns, err := g.Namespace(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: obj.GetNamespace()}})
+	if err != nil {
+		return nil, err
+	}
+	g.graph.Relationship(ns, "ResourceQuota", n)
+
+	return n, nil
+}
+
+// LimitRange adds a v1.LimitRange resource with its limits to the Graph.
+func (g *CoreV1Graph) LimitRange(obj *v1.LimitRange) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	for _, limit := range obj.Spec.Limits {
+		for constraint, resources := range map[string]v1.ResourceList{
+			"max":            limit.Max,
+			"min":            limit.Min,
+			"default":        limit.Default,
+			"defaultRequest": limit.DefaultRequest,
+		} {
+			for name, quantity := range resources {
+				n.Property(fmt.Sprintf("%s.%s.%s", limit.Type, constraint, name), quantity.String())
+			}
+		}
+	}
+
+	ns, err := g.Namespace(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: obj.GetNamespace()}})
+	if err != nil {
+		return nil, err
+	}
+	g.graph.Relationship(ns, "LimitRange", n)
+
 	return n, nil
 }
 
@@ -146,84 +170,98 @@ func (g *CoreV1Graph) Pod(pod *v1.Pod) (*Node, error) {
 		g.graph.Relationship(n, "Container", c)
 	}
 
-	if pod.Spec.ServiceAccountName != "" {
-		sa, err := g.ServiceAccount(pod.GetNamespace(), pod.Spec.ServiceAccountName)
+	if err := g.PodSpec(n, pod.GetNamespace(), &pod.Spec); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// PodSpec adds the service account and all config maps, secrets and persistent volume claims of a pod spec
+// to the Graph. They are related to the given node, which is either a pod or the owner of a pod template.
+func (g *CoreV1Graph) PodSpec(n *Node, namespace string, spec *v1.PodSpec) error {
+	if spec.ServiceAccountName != "" {
+		sa, err := g.ServiceAccount(namespace, spec.ServiceAccountName)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		g.graph.Relationship(n, "ServiceAccount", sa)
 	}
 
-	for _, imagePullSecret := range pod.Spec.ImagePullSecrets {
-		secret, err := g.Secret(pod.GetNamespace(), imagePullSecret.Name)
+	for _, ref := range PodSpecReferences(spec) {
+		var r *Node
+		var err error
+
+		switch ref.Kind {
+		case "ConfigMap":
+			r, err = g.ConfigMap(namespace, ref.Name)
+		case "Secret":
+			r, err = g.Secret(namespace, ref.Name)
+		case "PersistentVolumeClaim":
+			r, err = g.PersistentVolumeClaimRef(namespace, ref.Name)
+		}
 		if err != nil {
-			return nil, err
+			return err
 		}
-		g.graph.Relationship(n, "ImagePullSecret", secret)
+		g.graph.Relationship(n, ref.Label, r)
+	}
+
+	return nil
+}
+
+// PodSpecReference is a reference of a pod spec to a v1.ConfigMap, v1.Secret or v1.PersistentVolumeClaim.
+type PodSpecReference struct {
+	Label    string
+	Kind     string
+	Name     string
+	Optional bool
+}
+
+// PodSpecReferences returns all references of a pod spec to config maps, secrets and persistent volume claims.
+func PodSpecReferences(spec *v1.PodSpec) []PodSpecReference {
+	refs := []PodSpecReference{}
+	optional := func(o *bool) bool {
+		return o != nil && *o
+	}
+
+	for _, imagePullSecret := range spec.ImagePullSecrets {
+		refs = append(refs, PodSpecReference{Label: "ImagePullSecret", Kind: "Secret", Name: imagePullSecret.Name})
 	}
 
-	for _, volume := range pod.Spec.Volumes {
+	for _, volume := range spec.Volumes {
 		if volume.ConfigMap != nil {
-			cm, err := g.ConfigMap(pod.GetNamespace(), volume.ConfigMap.Name)
-			if err != nil {
-				return nil, err
-			}
-			g.graph.Relationship(n, "ConfigMap", cm)
+			refs = append(refs, PodSpecReference{Label: "ConfigMap", Kind: "ConfigMap", Name: volume.ConfigMap.Name, Optional: optional(volume.ConfigMap.Optional)})
 		}
 
 		if volume.Secret != nil {
-			secret, err := g.Secret(pod.GetNamespace(), volume.Secret.SecretName)
-			if err != nil {
-				return nil, err
-			}
-			g.graph.Relationship(n, "Secret", secret)
+			refs = append(refs, PodSpecReference{Label: "Secret", Kind: "Secret", Name: volume.Secret.SecretName, Optional: optional(volume.Secret.Optional)})
 		}
 
 		if volume.PersistentVolumeClaim != nil {
-			pvc, err := g.PersistentVolumeClaimRef(pod.GetNamespace(), volume.PersistentVolumeClaim.ClaimName)
-			if err != nil {
-				return nil, err
-			}
-			g.graph.Relationship(n, "PersistentVolumeClaim", pvc)
+			refs = append(refs, PodSpecReference{Label: "PersistentVolumeClaim", Kind: "PersistentVolumeClaim", Name: volume.PersistentVolumeClaim.ClaimName})
 		}
 
 		if volume.Projected != nil {
 			for _, source := range volume.Projected.Sources {
 				if source.ConfigMap != nil {
-					cm, err := g.ConfigMap(pod.GetNamespace(), source.ConfigMap.Name)
-					if err != nil {
-						return nil, err
-					}
-					g.graph.Relationship(n, "ConfigMap", cm)
+					refs = append(refs, PodSpecReference{Label: "ConfigMap", Kind: "ConfigMap", Name: source.ConfigMap.Name, Optional: optional(source.ConfigMap.Optional)})
 				}
 
 				if source.Secret != nil {
-					secret, err := g.Secret(pod.GetNamespace(), source.Secret.Name)
-					if err != nil {
-						return nil, err
-					}
-					g.graph.Relationship(n, "Secret", secret)
+					refs = append(refs, PodSpecReference{Label: "Secret", Kind: "Secret", Name: source.Secret.Name, Optional: optional(source.Secret.Optional)})
 				}
 			}
 		}
 	}
 
-	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+	for _, container := range append(spec.InitContainers, spec.Containers...) {
 		for _, envFrom := range container.EnvFrom {
 			if envFrom.ConfigMapRef != nil {
-				cm, err := g.ConfigMap(pod.GetNamespace(), envFrom.ConfigMapRef.Name)
-				if err != nil {
-					return nil, err
-				}
-				g.graph.Relationship(n, "ConfigMap", cm)
+				refs = append(refs, PodSpecReference{Label: "ConfigMap", Kind: "ConfigMap", Name: envFrom.ConfigMapRef.Name, Optional: optional(envFrom.ConfigMapRef.Optional)})
 			}
 
 			if envFrom.SecretRef != nil {
-				secret, err := g.Secret(pod.GetNamespace(), envFrom.SecretRef.Name)
-				if err != nil {
-					return nil, err
-				}
-				g.graph.Relationship(n, "Secret", secret)
+				refs = append(refs, PodSpecReference{Label: "Secret", Kind: "Secret", Name: envFrom.SecretRef.Name, Optional: optional(envFrom.SecretRef.Optional)})
 			}
 		}
 
@@ -233,32 +271,25 @@ func (g *CoreV1Graph) Pod(pod *v1.Pod) (*Node, error) {
 			}
 
 			if env.ValueFrom.ConfigMapKeyRef != nil {
-				cm, err := g.ConfigMap(pod.GetNamespace(), env.ValueFrom.ConfigMapKeyRef.Name)
-				if err != nil {
-					return nil, err
-				}
-				g.graph.Relationship(n, "ConfigMap", cm)
+				refs = append(refs, PodSpecReference{Label: "ConfigMap", Kind: "ConfigMap", Name: env.ValueFrom.ConfigMapKeyRef.Name, Optional: optional(env.ValueFrom.ConfigMapKeyRef.Optional)})
 			}
 
 			if env.ValueFrom.SecretKeyRef != nil {
-				secret, err := g.Secret(pod.GetNamespace(), env.ValueFrom.SecretKeyRef.Name)
-				if err != nil {
-					return nil, err
-				}
-				g.graph.Relationship(n, "Secret", secret)
+				refs = append(refs, PodSpecReference{Label: "Secret", Kind: "Secret", Name: env.ValueFrom.SecretKeyRef.Name, Optional: optional(env.ValueFrom.SecretKeyRef.Optional)})
 			}
 		}
 	}
 
-	return n, nil
+	return refs
 }
 
 // Container adds a v1.Container resource to the Graph.
 func (g *CoreV1Graph) Container(pod *v1.Pod, container v1.Container) (*Node, error) {
+	gvk := schema.FromAPIVersionAndKind(v1.GroupName, "Container")
 	n := g.graph.Node(
-		schema.FromAPIVersionAndKind(v1.GroupName, "Container"),
+		gvk,
 		&metav1.ObjectMeta{
-			UID:       ToUID(pod.GetUID(), container.Name),
+			UID:       g.graph.SyntheticUID(gvk, pod.GetNamespace(), pod.GetName()+"/"+container.Name),
 			Namespace: pod.GetNamespace(),
 			Name:      container.Name,
 		},
@@ -285,10 +316,11 @@ func (g *CoreV1Graph) Image(name string) (*Node, error) {
 		}
 	}
 
+	gvk := schema.FromAPIVersionAndKind("kubectl-graph/v1", "Image")
 	n := g.graph.Node(
-		schema.FromAPIVersionAndKind("kubectl-graph/v1", "Image"),
+		gvk,
 		&metav1.ObjectMeta{
-			UID:  ToUID(registry, image),
+			UID:  g.graph.SyntheticUID(gvk, "", registry+"/"+image),
 			Name: image,
 		},
 	)
@@ -304,10 +336,11 @@ func (g *CoreV1Graph) Image(name string) (*Node, error) {
 
 // Registry adds a v1.Registry resource to the Graph.
 func (g *CoreV1Graph) Registry(name string) (*Node, error) {
+	gvk := schema.FromAPIVersionAndKind("kubectl-graph/v1", "Registry")
 	n := g.graph.Node(
-		schema.FromAPIVersionAndKind("kubectl-graph/v1", "Registry"),
+		gvk,
 		&metav1.ObjectMeta{
-			UID:  ToUID(name),
+			UID:  g.graph.SyntheticUID(gvk, "", name),
 			Name: name,
 		},
 	)
@@ -353,7 +386,7 @@ func (g *CoreV1Graph) TypedLocalObjectReference(obj *v1.TypedLocalObjectReferenc
 	n := g.graph.Node(
 		schema.FromAPIVersionAndKind(v1.GroupName, *obj.APIGroup),
 		&metav1.ObjectMeta{
-			UID:       ToUID(obj.APIGroup, obj.Kind, obj.Name),
+			UID:       g.graph.SyntheticUID(schema.GroupVersionKind{Group: *obj.APIGroup, Kind: obj.Kind}, namespace, obj.Name),
 			Name:      obj.Name,
 			Namespace: namespace,
 		},
@@ -365,9 +398,8 @@ func (g *CoreV1Graph) TypedLocalObjectReference(obj *v1.TypedLocalObjectReferenc
 // Service adds a v1.Service resource to the Graph.
 func (g *CoreV1Graph) Service(obj *v1.Service) (*Node, error) {
 	switch obj.Spec.Type {
-	case v1.ServiceTypeClusterIP:
+	case v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, "":
 		return g.ServiceTypeClusterIP(obj)
-		// case v1.ServiceTypeNodePort:
 	case v1.ServiceTypeLoadBalancer:
 		return g.ServiceTypeLoadBalancer(obj)
 	case v1.ServiceTypeExternalName:
@@ -390,7 +422,7 @@ func (g *CoreV1Graph) ConfigMap(namespace string, name string) (*Node, error) {
 	n := g.graph.Node(
 		schema.FromAPIVersionAndKind(v1.GroupName, "ConfigMap"),
 		&metav1.ObjectMeta{
-			UID:       ToUID("ConfigMap", namespace, name),
+			UID:       g.graph.SyntheticUID(v1.SchemeGroupVersion.WithKind("ConfigMap"), namespace, name),
 			Namespace: namespace,
 			Name:      name,
 		},
@@ -412,7 +444,7 @@ func (g *CoreV1Graph) Secret(namespace string, name string) (*Node, error) {
 	n := g.graph.Node(
 		schema.FromAPIVersionAndKind(v1.GroupName, "Secret"),
 		&metav1.ObjectMeta{
-			UID:       ToUID("Secret", namespace, name),
+			UID:       g.graph.SyntheticUID(v1.SchemeGroupVersion.WithKind("Secret"), namespace, name),
 			Namespace: namespace,
 			Name:      name,
 		},
@@ -434,7 +466,7 @@ func (g *CoreV1Graph) ServiceAccount(namespace string, name string) (*Node, erro
 	n := g.graph.Node(
 		schema.FromAPIVersionAndKind(v1.GroupName, "ServiceAccount"),
 		&metav1.ObjectMeta{
-			UID:       ToUID("ServiceAccount", namespace, name),
+			UID:       g.graph.SyntheticUID(v1.SchemeGroupVersion.WithKind("ServiceAccount"), namespace, name),
 			Namespace: namespace,
 			Name:      name,
 		},
@@ -443,40 +475,116 @@ func (g *CoreV1Graph) ServiceAccount(namespace string, name string) (*Node, erro
 	return n, nil
 }
 
-// ServiceTypeClusterIP adds a v1.Service of type ClusterIP to the Graph.
-func (g *CoreV1Graph) ServiceTypeClusterIP(obj *v1.Service) (*Node, error) {
-	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), obj)
+// ServiceRef adds the v1.Service with the given name from the ObjectSource to the Graph.
+// A service which can not be retrieved is added as placeholder marked missing or forbidden.
+func (g *CoreV1Graph) ServiceRef(namespace string, name string) (*Node, error) {
+	gvk := v1.SchemeGroupVersion.WithKind("Service")
 
-	options := metav1.GetOptions{}
-	endpoints, err := g.graph.clientset.CoreV1().Endpoints(obj.GetNamespace()).Get(context.TODO(), obj.GetName(), options)
+	unstr, err := g.graph.get(gvk, namespace, name)
 	if err != nil {
+		reason, err := g.graph.Degrade(gvk, namespace, name, err)
+		if err != nil {
+			return nil, err
+		}
+
+		return g.graph.Ref(gvk, namespace, name).Placeholder(reason), nil
+	}
+
+	service := &v1.Service{}
+	if err := FromUnstructured(unstr, service); err != nil {
 		return nil, err
 	}
 
-	e, err := g.Endpoints(endpoints)
+	return g.Service(service)
+}
+
+// SelectServices adds all services matching the selector to the Graph. An empty namespace selects all namespaces.
+func (g *CoreV1Graph) SelectServices(namespace string, selector labels.Selector) ([]*Node, error) {
+	nodes := []*Node{}
+
+	gvk := v1.SchemeGroupVersion.WithKind("Service")
+	services, err := g.graph.list(gvk, namespace, selector)
 	if err != nil {
+		_, err := g.graph.Degrade(gvk, namespace, "", err)
+		return nodes, err
+	}
+
+	for _, unstr := range services {
+		if n, ok := g.graph.Nodes[unstr.GetUID()]; ok {
+			nodes = append(nodes, n)
+			continue
+		}
+
+		service := &v1.Service{}
+		if err := FromUnstructured(unstr, service); err != nil {
+			return nil, err
+		}
+
+		s, err := g.Service(service)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			nodes = append(nodes, s)
+		}
+	}
+
+	return nodes, nil
+}
+
+// EndpointsRef adds the v1.Endpoints with the given name from the ObjectSource to the Graph. Endpoints which
+// can not be retrieved are added as placeholder marked missing or forbidden. Local manifests do not contain
+// the endpoints which are maintained by the control plane, so missing endpoints are skipped for them.
+func (g *CoreV1Graph) EndpointsRef(namespace string, name string) (*Node, error) {
+	gvk := v1.SchemeGroupVersion.WithKind("Endpoints")
+
+	unstr, err := g.graph.get(gvk, namespace, name)
+	if apierrors.IsNotFound(err) && g.graph.local {
+		return nil, nil
+	}
+	if err != nil {
+		reason, err := g.graph.Degrade(gvk, namespace, name, err)
+		if err != nil {
+			return nil, err
+		}
+
+		return g.graph.Ref(gvk, namespace, name).Placeholder(reason), nil
+	}
+
+	endpoints := &v1.Endpoints{}
+	if err := FromUnstructured(unstr, endpoints); err != nil {
 		return nil, err
 	}
-	g.graph.Relationship(n, "Endpoints", e)
 
-	return n, nil
+	return g.Endpoints(endpoints)
 }
 
-// ServiceTypeLoadBalancer adds a v1.Service of type LoadBalancer to the Graph.
-func (g *CoreV1Graph) ServiceTypeLoadBalancer(obj *v1.Service) (*Node, error) {
+// ServiceTypeClusterIP adds a v1.Service of type ClusterIP to the Graph.
+func (g *CoreV1Graph) ServiceTypeClusterIP(obj *v1.Service) (*Node, error) {
 	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), obj)
 
-	options := metav1.GetOptions{}
-	endpoints, err := g.graph.clientset.CoreV1().Endpoints(obj.GetNamespace()).Get(context.TODO(), obj.GetName(), options)
+	e, err := g.EndpointsRef(obj.GetNamespace(), obj.GetName())
 	if err != nil {
 		return nil, err
 	}
+	if e != nil {
+		g.graph.Relationship(n, "Endpoints", e)
+	}
 
-	e, err := g.Endpoints(endpoints)
+	return n, nil
+}
+
+// ServiceTypeLoadBalancer adds a v1.Service of type LoadBalancer to the Graph.
+func (g *CoreV1Graph) ServiceTypeLoadBalancer(obj *v1.Service) (*Node, error) {
+	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), obj)
+
+	e, err := g.EndpointsRef(obj.GetNamespace(), obj.GetName())
 	if err != nil {
 		return nil, err
 	}
-	g.graph.Relationship(n, "Endpoints", e)
+	if e != nil {
+		g.graph.Relationship(n, "Endpoints", e)
+	}
 
 	return n, nil
 }
@@ -485,10 +593,11 @@ func (g *CoreV1Graph) ServiceTypeLoadBalancer(obj *v1.Service) (*Node, error) {
 func (g *CoreV1Graph) ServiceTypeExternalName(obj *v1.Service) (*Node, error) {
 	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), obj)
 
+	gvk := schema.FromAPIVersionAndKind(v1.GroupName, "ExternalName")
 	e := g.graph.Node(
-		schema.FromAPIVersionAndKind(v1.GroupName, "ExternalName"),
+		gvk,
 		&metav1.ObjectMeta{
-			UID:  ToUID(obj.Spec.ExternalName),
+			UID:  g.graph.SyntheticUID(gvk, "", obj.Spec.ExternalName),
 			Name: obj.Spec.ExternalName,
 		},
 	)
@@ -508,10 +617,11 @@ func (g *CoreV1Graph) Node(obj *v1.Node) (*Node, error) {
 		"OSImage":      obj.Status.NodeInfo.OSImage,
 	}
 	for kind, info := range infos {
+		gvk := schema.FromAPIVersionAndKind("kubectl-graph/v1", kind)
 		i := g.graph.Node(
-			schema.FromAPIVersionAndKind("kubectl-graph/v1", kind),
+			gvk,
 			&metav1.ObjectMeta{
-				UID:  ToUID(info),
+				UID:  g.graph.SyntheticUID(gvk, "", info),
 				Name: info,
 			},
 		)
@@ -549,7 +659,7 @@ func (g *CoreV1Graph) PersistentVolumeRef(name string) (*Node, error) {
 	n := g.graph.Node(
 		schema.FromAPIVersionAndKind(v1.GroupName, "PersistentVolume"),
 		&metav1.ObjectMeta{
-			UID:  ToUID("PersistentVolume", name),
+			UID:  g.graph.SyntheticUID(v1.SchemeGroupVersion.WithKind("PersistentVolume"), "", name),
 			Name: name,
 		},
 	)
@@ -593,7 +703,7 @@ func (g *CoreV1Graph) StorageClass(name string) (*Node, error) {
 	n := g.graph.Node(
 		schema.FromAPIVersionAndKind("storage.k8s.io/v1", "StorageClass"),
 		&metav1.ObjectMeta{
-			UID:  ToUID("StorageClass", name),
+			UID:  g.graph.SyntheticUID(schema.FromAPIVersionAndKind("storage.k8s.io/v1", "StorageClass"), "", name),
 			Name: name,
 		},
 	)
@@ -614,7 +724,7 @@ func (g *CoreV1Graph) PersistentVolumeClaimRef(namespace string, name string) (*
 	n := g.graph.Node(
 		schema.FromAPIVersionAndKind(v1.GroupName, "PersistentVolumeClaim"),
 		&metav1.ObjectMeta{
-			UID:       ToUID("PersistentVolumeClaim", namespace, name),
+			UID:       g.graph.SyntheticUID(v1.SchemeGroupVersion.WithKind("PersistentVolumeClaim"), namespace, name),
 			Namespace: namespace,
 			Name:      name,
 		},
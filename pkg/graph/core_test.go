@@ -0,0 +1,59 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestResourceQuota(t *testing.T) {
+	objs := testObjects(t, `
+apiVersion: v1
+kind: ResourceQuota
+metadata:
+  name: compute
+  namespace: shop
+  uid: quota-compute
+spec:
+  hard:
+    pods: "10"
+    requests.cpu: "4"
+status:
+  hard:
+    pods: "10"
+    requests.cpu: "4"
+  used:
+    pods: "3"
+    requests.cpu: 1500m
+`)
+
+	g, err := NewGraph(context.Background(), nil, objs, &Options{Concurrency: 1}, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := g.FindNode("v1", "ResourceQuota", "shop", "compute")
+	if n == nil {
+		t.Fatal("resource quota is missing")
+	}
+	if got, want := n.Properties, map[string]string{"hard.pods": "10", "hard.requests.cpu": "4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("properties = %v, want %v", got, want)
+	}
+	if got, want := n.Status.Properties(), map[string]interface{}{"used.pods": "3", "used.requests.cpu": "1500m"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("status properties = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,334 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Changes of nodes and relationships between two graphs.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// diffProperty is the node property and relationship attribute which marks the change in a combined Graph.
+const diffProperty = "diff"
+
+// diffColors are the colors of changed relationships in a combined Graph.
+var diffColors = map[string]string{
+	ChangeAdded:   "#34a853",
+	ChangeRemoved: "#ea4335",
+	ChangeChanged: "#fbbc05",
+}
+
+// NodeChange describes a node which was added, removed or changed.
+type NodeChange struct {
+	Change    string   `json:"change"`
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name"`
+	Fields    []string `json:"fields,omitempty"`
+}
+
+// RelationshipChange describes a relationship which was added, removed or changed.
+type RelationshipChange struct {
+	Change string   `json:"change"`
+	From   string   `json:"from"`
+	Label  string   `json:"label"`
+	To     string   `json:"to"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// DiffReport stores all changes between two graphs.
+type DiffReport struct {
+	Nodes         []NodeChange         `json:"nodes"`
+	Relationships []RelationshipChange `json:"relationships"`
+}
+
+// relationshipKey identifies a relationship by the identities of its nodes, its label and its scoped attributes.
+type relationshipKey struct {
+	from  nodeKey
+	label string
+	to    nodeKey
+	scope string
+}
+
+// Diff compares two graphs and returns a combined Graph with all nodes and relationships of both graphs
+// together with a report of all changes. Nodes are compared by apiVersion, kind, namespace and name, so
+// graphs of different clusters can be compared. A node changed when its labels, annotations or properties
+// differ and a relationship changed when its attributes differ. The status of the nodes is not compared,
+// because it changes without a change of the topology.
+//
+// The changed nodes and relationships of the combined Graph have a diff property with the change and
+// relationships are colored. Nodes and relationships which were removed keep their UID of the old Graph.
+func Diff(from *Graph, to *Graph) (*Graph, *DiffReport) {
+	options := *to.Options
+	g := newGraph(context.Background(), NewMemorySource(nil), nil, &options)
+	g.cluster = to.cluster
+	g.created = to.created
+
+	report := &DiffReport{
+		Nodes:         []NodeChange{},
+		Relationships: []RelationshipChange{},
+	}
+
+	uids := make(map[nodeKey]types.UID)
+
+	for _, node := range to.NodeList() {
+		n := node.copy()
+		if old := from.FindNode(node.APIVersion, node.Kind, node.Namespace, node.Name); old == nil {
+			report.Nodes = append(report.Nodes, nodeChange(ChangeAdded, n, nil))
+			n.Property(diffProperty, ChangeAdded)
+		} else if fields := nodeFields(old, node); len(fields) != 0 {
+			report.Nodes = append(report.Nodes, nodeChange(ChangeChanged, n, fields))
+			n.Property(diffProperty, ChangeChanged)
+		}
+		g.add(n)
+		uids[keyOf(n)] = n.UID
+	}
+
+	for _, node := range from.NodeList() {
+		if _, ok := uids[keyOf(node)]; ok {
+			continue
+		}
+
+		n := node.copy()
+		if _, ok := g.Nodes[n.UID]; ok {
+			n.UID = ToUID(UIDScheme, ChangeRemoved, n.UID)
+		}
+		report.Nodes = append(report.Nodes, nodeChange(ChangeRemoved, n, nil))
+		n.Property(diffProperty, ChangeRemoved)
+		g.add(n)
+		uids[keyOf(n)] = n.UID
+	}
+
+	old := make(map[relationshipKey]*Relationship)
+	for _, r := range from.RelationshipList() {
+		if key, ok := from.relationshipKey(r); ok {
+			old[key] = r
+		}
+	}
+
+	seen := make(map[relationshipKey]bool)
+	for _, r := range to.RelationshipList() {
+		key, ok := to.relationshipKey(r)
+		if !ok {
+			continue
+		}
+		seen[key] = true
+
+		change := ""
+		fields := []string{}
+		if o, ok := old[key]; !ok {
+			change = ChangeAdded
+		} else if !equalMaps(o.Attr, r.Attr) {
+			change, fields = ChangeChanged, []string{"attributes"}
+		}
+
+		g.relationship(uids, key, r, change, fields, report)
+	}
+
+	for _, r := range from.RelationshipList() {
+		key, ok := from.relationshipKey(r)
+		if !ok || seen[key] {
+			continue
+		}
+
+		g.relationship(uids, key, r, ChangeRemoved, nil, report)
+	}
+
+	report.sort()
+
+	return g, report
+}
+
+// copy returns a copy of the node with its own properties.
+func (n *Node) copy() *Node {
+	c := *n
+	c.Properties = make(map[string]string, len(n.Properties))
+	for key, value := range n.Properties {
+		c.Properties[key] = value
+	}
+
+	return &c
+}
+
+// add adds a node to the Graph as it is.
+func (g *Graph) add(node *Node) {
+	g.Nodes[node.UID] = node
+	g.index.add(node)
+}
+
+// relationshipKey returns the identity of a relationship, which is false when one of its nodes is unknown.
+func (g *Graph) relationshipKey(r *Relationship) (relationshipKey, bool) {
+	from, ok := g.Nodes[r.From]
+	if !ok {
+		return relationshipKey{}, false
+	}
+	to, ok := g.Nodes[r.To]
+	if !ok {
+		return relationshipKey{}, false
+	}
+
+	return relationshipKey{from: keyOf(from), label: r.Label, to: keyOf(to), scope: r.scopeKey()}, true
+}
+
+// relationship adds a copy of the relationship between the nodes of the combined Graph and reports its change.
+func (g *Graph) relationship(uids map[nodeKey]types.UID, key relationshipKey, r *Relationship, change string, fields []string, report *DiffReport) {
+	c := &Relationship{
+		From:  uids[key.from],
+		Label: r.Label,
+		To:    uids[key.to],
+		Attr:  make(map[string]string, len(r.Attr)),
+		Scope: r.Scope,
+	}
+	for k, v := range r.Attr {
+		c.Attr[k] = v
+	}
+	g.Relationships[c.To] = append(g.Relationships[c.To], c)
+
+	if change == "" {
+		return
+	}
+
+	c.Attr[diffProperty] = change
+	c.Attr["color"] = diffColors[change]
+	report.Relationships = append(report.Relationships, RelationshipChange{
+		Change: change,
+		From:   key.from.String(),
+		Label:  r.Label,
+		To:     key.to.String(),
+		Fields: fields,
+	})
+}
+
+// nodeFields returns the fields which differ between two nodes with the same identity.
+func nodeFields(a *Node, b *Node) []string {
+	fields := []string{}
+	if !equalMaps(a.Labels, b.Labels) {
+		fields = append(fields, "labels")
+	}
+	if !equalMaps(a.Annotations, b.Annotations) {
+		fields = append(fields, "annotations")
+	}
+	if !equalMaps(a.Properties, b.Properties) {
+		fields = append(fields, "properties")
+	}
+
+	return fields
+}
+
+// equalMaps reports whether two maps have the same entries, a nil map equals an empty map.
+func equalMaps(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if v, ok := b[key]; !ok || v != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nodeChange returns the change of a node.
+func nodeChange(change string, n *Node, fields []string) NodeChange {
+	return NodeChange{Change: change, Kind: n.Kind, Namespace: n.Namespace, Name: n.Name, Fields: fields}
+}
+
+// String returns the kind, namespace and name of the node identity.
+func (k nodeKey) String() string {
+	if k.namespace == "" {
+		return k.kind + "/" + k.name
+	}
+
+	return k.kind + "/" + k.namespace + "/" + k.name
+}
+
+// Object returns the kind, namespace and name of the changed node.
+func (c NodeChange) Object() string {
+	return nodeKey{kind: c.Kind, namespace: c.Namespace, name: c.Name}.String()
+}
+
+// sort orders the changes by object and relationship.
+func (r *DiffReport) sort() {
+	sort.SliceStable(r.Nodes, func(i, j int) bool {
+		return r.Nodes[i].Object() < r.Nodes[j].Object()
+	})
+	sort.SliceStable(r.Relationships, func(i, j int) bool {
+		a, b := r.Relationships[i], r.Relationships[j]
+		return a.From+"\x00"+a.Label+"\x00"+a.To < b.From+"\x00"+b.Label+"\x00"+b.To
+	})
+}
+
+// Empty reports whether the graphs do not differ.
+func (r *DiffReport) Empty() bool {
+	return len(r.Nodes) == 0 && len(r.Relationships) == 0
+}
+
+// Write writes the report in text or json format.
+func (r *DiffReport) Write(w io.Writer, format string) error {
+	if format == "json" {
+		return r.WriteJSON(w)
+	}
+
+	return r.WriteText(w)
+}
+
+// WriteText writes the changed nodes and relationships as tables.
+func (r *DiffReport) WriteText(w io.Writer) error {
+	if r.Empty() {
+		_, err := fmt.Fprintln(w, "No changes found.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if len(r.Nodes) != 0 {
+		fmt.Fprintln(tw, "CHANGE\tOBJECT\tFIELDS")
+		for _, c := range r.Nodes {
+			fmt.Fprintln(tw, strings.Join([]string{c.Change, c.Object(), strings.Join(c.Fields, ",")}, "\t"))
+		}
+	}
+	if len(r.Relationships) != 0 {
+		if len(r.Nodes) != 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, "CHANGE\tFROM\tRELATIONSHIP\tTO\tFIELDS")
+		for _, c := range r.Relationships {
+			fmt.Fprintln(tw, strings.Join([]string{c.Change, c.From, c.Label, c.To, strings.Join(c.Fields, ",")}, "\t"))
+		}
+	}
+
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as JSON object.
+func (r *DiffReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// testDiffGraph returns an empty Graph of a cluster, whose nodes get UIDs of that cluster.
+func testDiffGraph(cluster string) (*Graph, func(kind string, name string, labels map[string]string) *Node) {
+	g := newGraph(context.Background(), NewMemorySource(nil), nil, &Options{})
+	g.cluster = cluster
+
+	node := func(kind string, name string, labels map[string]string) *Node {
+		gvk := corev1.SchemeGroupVersion.WithKind(kind)
+		if kind == "NetworkPolicy" || kind == "IPBlock" {
+			gvk = networkingv1.SchemeGroupVersion.WithKind(kind)
+		}
+		return g.Node(gvk, &metav1.ObjectMeta{
+			UID:       types.UID(cluster + "/" + kind + "/" + name),
+			Namespace: "shop",
+			Name:      name,
+			Labels:    labels,
+		})
+	}
+
+	return g, node
+}
+
+func TestDiffNodes(t *testing.T) {
+	from, fromNode := testDiffGraph("a")
+	fromNode("Service", "api", map[string]string{"app": "api"})
+	fromNode("Service", "web", map[string]string{"app": "web"})
+	fromNode("ConfigMap", "legacy", nil)
+	fromNode("Secret", "tls", nil).Property("type", "kubernetes.io/tls")
+
+	to, toNode := testDiffGraph("b")
+	toNode("Service", "api", map[string]string{"app": "api"})
+	toNode("Service", "web", map[string]string{"app": "web", "tier": "frontend"})
+	toNode("ConfigMap", "settings", nil)
+	toNode("Secret", "tls", nil).Property("type", "Opaque")
+
+	g, report := Diff(from, to)
+
+	want := []NodeChange{
+		{Change: ChangeRemoved, Kind: "ConfigMap", Namespace: "shop", Name: "legacy"},
+		{Change: ChangeAdded, Kind: "ConfigMap", Namespace: "shop", Name: "settings"},
+		{Change: ChangeChanged, Kind: "Secret", Namespace: "shop", Name: "tls", Fields: []string{"properties"}},
+		{Change: ChangeChanged, Kind: "Service", Namespace: "shop", Name: "web", Fields: []string{"labels"}},
+	}
+	if !reflect.DeepEqual(report.Nodes, want) {
+		t.Errorf("node changes = %+v, want %+v", report.Nodes, want)
+	}
+
+	marks := []struct {
+		kind   string
+		name   string
+		change string
+	}{
+		{kind: "ConfigMap", name: "legacy", change: ChangeRemoved},
+		{kind: "ConfigMap", name: "settings", change: ChangeAdded},
+		{kind: "Service", name: "api"},
+	}
+	for _, mark := range marks {
+		n := g.FindNode("v1", mark.kind, "shop", mark.name)
+		if n == nil {
+			t.Fatalf("combined graph is missing %s/%s", mark.kind, mark.name)
+		}
+		if n.Properties[diffProperty] != mark.change {
+			t.Errorf("%s/%s is marked %q, want %q", mark.kind, mark.name, n.Properties[diffProperty], mark.change)
+		}
+	}
+	if uid := g.FindNode("v1", "ConfigMap", "shop", "legacy").UID; uid != "a/ConfigMap/legacy" {
+		t.Errorf("removed node has uid %s, want the uid of the old graph", uid)
+	}
+}
+
+func TestDiffRelationships(t *testing.T) {
+	build := func(cluster string, rules map[string]string, ports string) *Graph {
+		g, node := testDiffGraph(cluster)
+		policy := node("NetworkPolicy", "api", nil)
+		block := node("IPBlock", "10.0.0.0/8", nil)
+		for action, except := range rules {
+			g.ScopedRelationship(block, "Ingress", policy, map[string]string{"action": action, "except": except}).Attribute("ports", ports)
+		}
+		g.Relationship(node("Service", "api", nil), "Endpoints", node("Endpoints", "api", nil))
+		return g
+	}
+
+	tests := []struct {
+		name  string
+		from  *Graph
+		to    *Graph
+		want  []RelationshipChange
+		rules int
+	}{
+		{
+			name:  "equal rules",
+			from:  build("a", map[string]string{"Allow": "10.1.0.0/16", "Deny": "10.2.0.0/16"}, "TCP/80"),
+			to:    build("b", map[string]string{"Allow": "10.1.0.0/16", "Deny": "10.2.0.0/16"}, "TCP/80"),
+			want:  []RelationshipChange{},
+			rules: 2,
+		},
+		{
+			name: "changed attribute",
+			from: build("a", map[string]string{"Allow": "10.1.0.0/16"}, "TCP/80"),
+			to:   build("b", map[string]string{"Allow": "10.1.0.0/16"}, "TCP/443"),
+			want: []RelationshipChange{
+				{Change: ChangeChanged, From: "IPBlock/shop/10.0.0.0/8", Label: "Ingress", To: "NetworkPolicy/shop/api", Fields: []string{"attributes"}},
+			},
+			rules: 1,
+		},
+		{
+			name: "changed scope",
+			from: build("a", map[string]string{"Allow": "10.1.0.0/16"}, "TCP/80"),
+			to:   build("b", map[string]string{"Allow": "10.2.0.0/16"}, "TCP/80"),
+			want: []RelationshipChange{
+				{Change: ChangeAdded, From: "IPBlock/shop/10.0.0.0/8", Label: "Ingress", To: "NetworkPolicy/shop/api", Fields: []string{}},
+				{Change: ChangeRemoved, From: "IPBlock/shop/10.0.0.0/8", Label: "Ingress", To: "NetworkPolicy/shop/api"},
+			},
+			rules: 2,
+		},
+		{
+			name: "added and removed rules",
+			from: build("a", map[string]string{"Allow": "10.1.0.0/16"}, "TCP/80"),
+			to:   build("b", map[string]string{"Deny": "10.1.0.0/16"}, "TCP/80"),
+			want: []RelationshipChange{
+				{Change: ChangeAdded, From: "IPBlock/shop/10.0.0.0/8", Label: "Ingress", To: "NetworkPolicy/shop/api", Fields: []string{}},
+				{Change: ChangeRemoved, From: "IPBlock/shop/10.0.0.0/8", Label: "Ingress", To: "NetworkPolicy/shop/api"},
+			},
+			rules: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, report := Diff(tt.from, tt.to)
+			if !reflect.DeepEqual(report.Relationships, tt.want) {
+				t.Errorf("relationship changes = %+v, want %+v", report.Relationships, tt.want)
+			}
+			if len(report.Nodes) != 0 {
+				t.Errorf("node changes = %+v, want none", report.Nodes)
+			}
+
+			rules := 0
+			for _, r := range g.RelationshipList() {
+				if r.Label == "Ingress" {
+					rules++
+				}
+			}
+			if rules != tt.rules {
+				t.Errorf("combined graph has %d rules, want %d", rules, tt.rules)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// maxEventReasons is the number of distinct reasons of the latest warnings which are attached to a node.
+	maxEventReasons = 3
+	// DefaultEventWindow represents the default window in which warnings are considered recent.
+	DefaultEventWindow time.Duration = time.Hour
+)
+
+// Events fetches the events.k8s.io/v1 Events of all namespaces in the Graph from its ObjectSource and attaches
+// the warnings which were observed within the window to the nodes they are regarding. The events of cluster
+// nodes are recorded in the default namespace, which is only listed when the Graph contains cluster nodes.
+func (g *Graph) Events(window time.Duration) error {
+	events := []eventsv1.Event{}
+
+	namespaces := map[string]bool{}
+	for _, node := range g.Nodes {
+		switch {
+		case node.GetNamespace() != "":
+			namespaces[node.GetNamespace()] = true
+		case node.GroupVersionKind() == corev1.SchemeGroupVersion.WithKind("Node"):
+			namespaces[metav1.NamespaceDefault] = true
+		}
+	}
+
+	for namespace := range namespaces {
+		gvk := eventsv1.SchemeGroupVersion.WithKind("Event")
+		list, err := g.list(gvk, namespace, labels.Everything())
+		if err != nil {
+			if _, err := g.Degrade(gvk, namespace, "", err); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, obj := range list {
+			event := eventsv1.Event{}
+			if err := FromUnstructured(obj, &event); err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+	}
+
+	g.EventList(events, g.created.Add(-window))
+	return nil
+}
+
+// EventList attaches the number of warnings, the latest reasons, message and time as properties to the nodes which are regarded by the events.
+// Warnings which were observed before since are ignored.
+func (g *Graph) EventList(events []eventsv1.Event, since time.Time) {
+	warnings := []eventsv1.Event{}
+	for _, event := range events {
+		if event.Type != corev1.EventTypeWarning {
+			continue
+		}
+		if EventTime(event).Before(since) {
+			continue
+		}
+		if _, ok := g.Nodes[event.Regarding.UID]; !ok {
+			continue
+		}
+		warnings = append(warnings, event)
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return EventTime(warnings[i]).After(EventTime(warnings[j]))
+	})
+
+	counts := map[types.UID]int32{}
+	reasons := map[types.UID][]string{}
+
+	for _, event := range warnings {
+		uid := event.Regarding.UID
+		node := g.Nodes[uid]
+
+		if _, ok := counts[uid]; !ok {
+			node.Property("lastWarning", EventTime(event).UTC().Format(time.RFC3339))
+			node.Property("lastMessage", event.Note)
+		}
+
+		switch {
+		case event.Series != nil:
+			counts[uid] += event.Series.Count
+		case event.DeprecatedCount > 0:
+			counts[uid] += event.DeprecatedCount
+		default:
+			counts[uid]++
+		}
+
+		if len(reasons[uid]) < maxEventReasons && !MatchAny(reasons[uid], func(reason string) bool { return reason == event.Reason }) {
+			reasons[uid] = append(reasons[uid], event.Reason)
+		}
+	}
+
+	for uid, count := range counts {
+		g.Nodes[uid].Property("warnings", strconv.Itoa(int(count)))
+		g.Nodes[uid].Property("reasons", strings.Join(reasons[uid], ", "))
+	}
+}
+
+// EventTime returns the time when an event was observed last.
+func EventTime(event eventsv1.Event) time.Time {
+	switch {
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.DeprecatedLastTimestamp.IsZero():
+		return event.DeprecatedLastTimestamp.Time
+	}
+
+	return event.CreationTimestamp.Time
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/steveteuber/kubectl-graph/pkg/apis/externalsecrets/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	Register(v1.SchemeGroupVersion.WithKind("ExternalSecret"), Typed((*Graph).ExternalSecretsV1, (*ExternalSecretsV1Graph).ExternalSecret))
+	Register(v1.SchemeGroupVersion.WithKind("SecretStore"), Typed((*Graph).ExternalSecretsV1, (*ExternalSecretsV1Graph).SecretStore))
+	Register(v1.SchemeGroupVersion.WithKind("ClusterSecretStore"), Typed((*Graph).ExternalSecretsV1, (*ExternalSecretsV1Graph).ClusterSecretStore))
+
+	Register(v1.BetaGroupVersion.WithKind("ExternalSecret"), Typed((*Graph).ExternalSecretsV1, (*ExternalSecretsV1Graph).ExternalSecret))
+	Register(v1.BetaGroupVersion.WithKind("SecretStore"), Typed((*Graph).ExternalSecretsV1, (*ExternalSecretsV1Graph).SecretStore))
+	Register(v1.BetaGroupVersion.WithKind("ClusterSecretStore"), Typed((*Graph).ExternalSecretsV1, (*ExternalSecretsV1Graph).ClusterSecretStore))
+}
+
+// ExternalSecretsV1Graph is used to graph all External Secrets Operator resources.
+type ExternalSecretsV1Graph struct {
+	graph *Graph
+}
+
+// NewExternalSecretsV1Graph creates a new ExternalSecretsV1Graph.
+func NewExternalSecretsV1Graph(g *Graph) *ExternalSecretsV1Graph {
+	return &ExternalSecretsV1Graph{
+		graph: g,
+	}
+}
+
+// ExternalSecretsV1 retrieves the ExternalSecretsV1Graph.
+func (g *Graph) ExternalSecretsV1() *ExternalSecretsV1Graph {
+	return g.externalSecretsV1
+}
+
+// ExternalSecret adds a v1.ExternalSecret resource to the Graph.
+func (g *ExternalSecretsV1Graph) ExternalSecret(obj *v1.ExternalSecret) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if obj.Spec.SecretStoreRef.Name != "" {
+		kind, namespace := "SecretStore", obj.GetNamespace()
+		if obj.Spec.SecretStoreRef.Kind == "ClusterSecretStore" {
+			kind, namespace = "ClusterSecretStore", metav1.NamespaceNone
+		}
+
+		store := g.Store(kind, namespace, obj.Spec.SecretStoreRef.Name)
+		r := g.graph.Relationship(n, kind, store)
+
+		keys := []string{}
+		for _, data := range obj.Spec.Data {
+			keys = append(keys, data.RemoteRef.Key)
+		}
+		for _, data := range obj.Spec.DataFrom {
+			if key := DataFromKey(data); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) != 0 {
+			r.Attribute("keys", strings.Join(keys, ", "))
+		}
+	}
+
+	if obj.Spec.Target.CreationPolicy == "None" {
+		return n, nil
+	}
+
+	name := obj.Spec.Target.Name
+	if name == "" {
+		name = obj.GetName()
+	}
+
+	s, err := g.graph.CoreV1().Secret(obj.GetNamespace(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	r := g.graph.Relationship(n, "Secret", s)
+	if obj.Spec.Target.CreationPolicy != "" {
+		r.Attribute("creationPolicy", obj.Spec.Target.CreationPolicy)
+	}
+
+	return n, nil
+}
+
+// Store resolves an existing secret store of any version or adds a new node for it with the version of this package.
+// An ExternalSecret may refer to a store of another version, so stores are resolved by group and kind.
+func (g *ExternalSecretsV1Graph) Store(kind string, namespace string, name string) *Node {
+	for _, n := range g.graph.NodesByKind(v1.SchemeGroupVersion.WithKind(kind).GroupKind(), namespace) {
+		if n.GetNamespace() == namespace && n.GetName() == name {
+			return n
+		}
+	}
+
+	return g.graph.Ref(v1.SchemeGroupVersion.WithKind(kind), namespace, name)
+}
+
+// DataFromKey returns the remote key of a v1.ExternalSecretDataFrom, or a description of the query of a find.
+func DataFromKey(data v1.ExternalSecretDataFrom) string {
+	switch {
+	case data.Extract != nil:
+		return data.Extract.Key
+	case data.Find != nil && data.Find.Name != nil:
+		return "regexp:" + data.Find.Name.RegExp
+	case data.Find != nil && data.Find.Path != nil:
+		return "path:" + *data.Find.Path
+	case data.Find != nil && len(data.Find.Tags) != 0:
+		tags := []string{}
+		for key, value := range data.Find.Tags {
+			tags = append(tags, key+"="+value)
+		}
+		sort.Strings(tags)
+		return "tags:" + strings.Join(tags, ",")
+	}
+
+	return ""
+}
+
+// SecretStore adds a v1.SecretStore resource to the Graph.
+func (g *ExternalSecretsV1Graph) SecretStore(obj *v1.SecretStore) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.Provider(n, obj.Spec)
+}
+
+// ClusterSecretStore adds a v1.ClusterSecretStore resource to the Graph.
+func (g *ExternalSecretsV1Graph) ClusterSecretStore(obj *v1.ClusterSecretStore) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.Provider(n, obj.Spec)
+}
+
+// Provider adds the provider of a secret store to the Graph.
+func (g *ExternalSecretsV1Graph) Provider(n *Node, spec v1.SecretStoreSpec) error {
+	names := []string{}
+	for name := range spec.Provider {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > 1 {
+		return fmt.Errorf("%s: secret store has more than one provider: %s", n.GetName(), strings.Join(names, ", "))
+	}
+
+	for _, name := range names {
+		gvk := schema.FromAPIVersionAndKind("kubectl-graph/v1", "SecretProvider")
+		p := g.graph.Node(
+			gvk,
+			&metav1.ObjectMeta{
+				UID:  g.graph.SyntheticUID(gvk, "", name),
+				Name: name,
+			},
+		)
+		g.graph.Relationship(n, "SecretProvider", p)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"github.com/steveteuber/kubectl-graph/pkg/apis/gatekeeper/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	// The kind of a constraint is defined by its ConstraintTemplate, so the resolver is registered for all kinds.
+	for _, version := range []string{"v1", "v1beta1", "v1alpha1"} {
+		gvk := schema.GroupVersionKind{Group: v1beta1.GroupName, Version: version}
+		Register(gvk, Typed((*Graph).ConstraintsV1beta1, (*ConstraintsV1beta1Graph).Constraint))
+		Depends(gvk, corev1.SchemeGroupVersion.WithKind("Namespace"))
+	}
+}
+
+// ConstraintsV1beta1Graph is used to graph all Gatekeeper constraints.
+type ConstraintsV1beta1Graph struct {
+	graph *Graph
+}
+
+// NewConstraintsV1beta1Graph creates a new ConstraintsV1beta1Graph.
+func NewConstraintsV1beta1Graph(g *Graph) *ConstraintsV1beta1Graph {
+	return &ConstraintsV1beta1Graph{
+		graph: g,
+	}
+}
+
+// ConstraintsV1beta1 retrieves the ConstraintsV1beta1Graph.
+func (g *Graph) ConstraintsV1beta1() *ConstraintsV1beta1Graph {
+	return g.constraintsV1beta1
+}
+
+// Constraint adds a v1beta1.Constraint resource to the Graph. The constraint governs all nodes of the Graph
+// which it matches and is violated by all resources which the last audit reported.
+func (g *ConstraintsV1beta1Graph) Constraint(obj *v1beta1.Constraint) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	g.graph.Defer(n, func() error {
+		return g.Govern(n, obj)
+	})
+
+	for _, violation := range obj.Status.Violations {
+		gvk := schema.GroupVersionKind{Group: violation.Group, Version: violation.Version, Kind: violation.Kind}
+		resource := g.graph.Ref(gvk, violation.Namespace, violation.Name)
+
+		scope := map[string]string{}
+		if violation.EnforcementAction != "" {
+			scope["action"] = violation.EnforcementAction
+		}
+		g.graph.Wgpolicyk8sV1alpha2().Violation(resource, n, "fail", scope)
+	}
+
+	return n, nil
+}
+
+// Govern evaluates the match of a constraint against the nodes of the Graph and adds a Governs relationship
+// to every matched node. It is deferred until all objects are resolved, so the constraint matches the related
+// objects of the Graph as well.
+func (g *ConstraintsV1beta1Graph) Govern(n *Node, obj *v1beta1.Constraint) error {
+	action := obj.Spec.EnforcementAction
+	if action == "" {
+		action = "deny"
+	}
+
+	for _, node := range g.graph.NodeList() {
+		if node.GetUID() == n.GetUID() || node.Synthetic() || node.Properties[placeholderProperty] != "" {
+			continue
+		}
+
+		match, err := g.Match(obj.Spec.Match, node)
+		if err != nil {
+			return err
+		}
+		if match {
+			g.graph.Relationship(n, "Governs", node).Attribute("action", action)
+		}
+	}
+
+	return nil
+}
+
+// Match reports whether an object matches all conditions of a v1beta1.Match. The namespace conditions
+// only apply to namespaced objects and namespaces.
+func (g *ConstraintsV1beta1Graph) Match(m v1beta1.Match, obj *Node) (bool, error) {
+	gvk := obj.GroupVersionKind()
+
+	if len(m.Kinds) != 0 && !g.MatchKinds(m.Kinds, gvk) {
+		return false, nil
+	}
+
+	switch m.Scope {
+	case "Cluster":
+		if obj.GetNamespace() != "" {
+			return false, nil
+		}
+	case "Namespaced":
+		if obj.GetNamespace() == "" {
+			return false, nil
+		}
+	}
+
+	if m.Name != "" && !MatchWildcard(m.Name, obj.GetName()) {
+		return false, nil
+	}
+
+	if m.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(m.LabelSelector)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			return false, nil
+		}
+	}
+
+	namespace := obj.GetNamespace()
+	if gvk.Kind == "Namespace" && gvk.Group == "" {
+		namespace = obj.GetName()
+	}
+	if namespace == "" {
+		return true, nil
+	}
+
+	if len(m.Namespaces) != 0 && !MatchAny(m.Namespaces, func(ns string) bool { return MatchWildcard(ns, namespace) }) {
+		return false, nil
+	}
+	if MatchAny(m.ExcludedNamespaces, func(ns string) bool { return MatchWildcard(ns, namespace) }) {
+		return false, nil
+	}
+
+	if m.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(m.NamespaceSelector)
+		if err != nil {
+			return false, err
+		}
+
+		namespaceLabels, err := g.graph.NetworkingV1().NamespaceLabels(namespace)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(namespaceLabels) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// MatchKinds reports whether a group and kind is selected by any of the v1beta1.Kinds.
+func (g *ConstraintsV1beta1Graph) MatchKinds(kinds []v1beta1.Kinds, gvk schema.GroupVersionKind) bool {
+	for _, k := range kinds {
+		group := len(k.APIGroups) == 0 || MatchAny(k.APIGroups, func(group string) bool { return group == "*" || group == gvk.Group })
+		kind := len(k.Kinds) == 0 || MatchAny(k.Kinds, func(kind string) bool { return kind == "*" || kind == gvk.Kind })
+		if group && kind {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/steveteuber/kubectl-graph/pkg/apis/gatekeeper/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConstraintMatch(t *testing.T) {
+	g, nodes := testMatchGraph(t)
+
+	tests := []struct {
+		name   string
+		match  v1beta1.Match
+		object string
+		want   bool
+	}{
+		{"empty", v1beta1.Match{}, "Pod/web-1", true},
+		{"kind", v1beta1.Match{Kinds: []v1beta1.Kinds{{APIGroups: []string{""}, Kinds: []string{"Pod"}}}}, "Pod/web-1", true},
+		{"other group", v1beta1.Match{Kinds: []v1beta1.Kinds{{APIGroups: []string{""}, Kinds: []string{"Deployment"}}}}, "Deployment/web", false},
+		{"wildcard group", v1beta1.Match{Kinds: []v1beta1.Kinds{{APIGroups: []string{"*"}, Kinds: []string{"Deployment"}}}}, "Deployment/web", true},
+		{"wildcard kind", v1beta1.Match{Kinds: []v1beta1.Kinds{{APIGroups: []string{"apps"}, Kinds: []string{"*"}}}}, "Deployment/web", true},
+		{"any kinds", v1beta1.Match{Kinds: []v1beta1.Kinds{{Kinds: []string{"Pod"}}, {Kinds: []string{"ConfigMap"}}}}, "ConfigMap/settings", true},
+		{"cluster scope", v1beta1.Match{Scope: "Cluster"}, "Node/worker-1", true},
+		{"namespaced scope", v1beta1.Match{Scope: "Namespaced"}, "Node/worker-1", false},
+		{"wildcard name", v1beta1.Match{Name: "web-*"}, "Pod/web-1", true},
+		{"other name", v1beta1.Match{Name: "web-*"}, "Deployment/web", false},
+		{"label selector", v1beta1.Match{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}}, "Deployment/web", true},
+		{"other label selector", v1beta1.Match{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}}, "ConfigMap/settings", false},
+		{"wildcard namespace", v1beta1.Match{Namespaces: []string{"sh*"}}, "Pod/web-1", true},
+		{"other namespace", v1beta1.Match{Namespaces: []string{"sh*"}}, "ConfigMap/settings", false},
+		{"excluded namespace", v1beta1.Match{ExcludedNamespaces: []string{"d*"}}, "ConfigMap/settings", false},
+		{"excluded namespace itself", v1beta1.Match{ExcludedNamespaces: []string{"dev"}}, "Namespace/dev", false},
+		{"not excluded namespace", v1beta1.Match{ExcludedNamespaces: []string{"dev"}}, "Pod/web-1", true},
+		{"namespaces of cluster object", v1beta1.Match{Namespaces: []string{"shop"}, ExcludedNamespaces: []string{"*"}}, "Node/worker-1", true},
+		{"namespace selector", v1beta1.Match{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}}, "Pod/web-1", true},
+		{"other namespace selector", v1beta1.Match{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}}, "ConfigMap/settings", false},
+		{"namespace selector of namespace", v1beta1.Match{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}}, "Namespace/shop", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, ok := nodes[tt.object]
+			if !ok {
+				t.Fatalf("node %s not found", tt.object)
+			}
+
+			got, err := g.ConstraintsV1beta1().Match(tt.match, obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
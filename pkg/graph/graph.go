@@ -16,35 +16,73 @@ package graph
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"embed"
 	"encoding/json"
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/yaml"
 )
 
 const (
 	// DefaultNodeNameLimit represents the default limit to truncate the node name to N characters.
 	DefaultNodeNameLimit int = 12
+	// DefaultConcurrency represents the default number of objects which are resolved concurrently.
+	DefaultConcurrency int = 4
 )
 
 var (
 	//go:embed templates/*.tmpl
 	templateFiles embed.FS
 	templates     *template.Template
+
+	// styleAttributes are relationship attributes which only affect how a relationship is drawn.
+	styleAttributes = map[string]bool{
+		"color": true,
+		"style": true,
+	}
+
+	// dotEscaper escapes a value for a quoted string of the DOT language, newlines become line breaks of a label.
+	dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	// syntheticKinds are the kinds of nodes which do not represent a Kubernetes object.
+	syntheticKinds = map[string]bool{
+		"Cluster":      true,
+		"Container":    true,
+		"Entity":       true,
+		"ExternalName": true,
+		"FQDN":         true,
+		"Host":         true,
+		"IPBlock":      true,
+	}
+
+	// setAttributes are relationship attributes which contain a comma separated set of values.
+	// They are merged when the same relationship is added several times.
+	setAttributes = map[string]bool{
+		"containers": true,
+		"keys":       true,
+		"parameter":  true,
+		"ports":      true,
+		"rules":      true,
+		"tag":        true,
+		"type":       true,
+	}
 )
 
 func init() {
@@ -63,6 +101,9 @@ func init() {
 			}
 			return strings.Trim(string(b), "\n")
 		},
+		"dot": func(s string) string {
+			return dotEscaper.Replace(s)
+		},
 		"underscore": func(s string) string {
 			re := regexp.MustCompile(`[^A-Za-z0-9]+`)
 			return re.ReplaceAllString(strings.ToLower(s), "_")
@@ -85,46 +126,92 @@ func init() {
 	template.Must(templates.ParseFS(templateFiles, "templates/*.tmpl"))
 }
 
-// Graph stores nodes and relationships between them.
+// Graph stores nodes and relationships between them. Nodes are indexed, so they must be added with Node.
 type Graph struct {
 	Nodes         map[types.UID]*Node
 	Relationships map[types.UID][]*Relationship
 	Options       *Options
-
-	clientset *kubernetes.Clientset
-
-	coreV1       *CoreV1Graph
-	networkingV1 *NetworkingV1Graph
-	routeV1      *RouteV1Graph
+	Warnings      []Warning
+
+	// ctx is the context of the run, which is used by all lookups of the resolvers.
+	ctx    context.Context
+	source ObjectSource
+	// local is true when related objects are retrieved from a MemorySource. Manifests usually contain
+	// workloads instead of pods, so workloads with a pod template stand in for their pods.
+	local   bool
+	cluster string
+	created time.Time
+	objects []*unstructured.Unstructured
+	// kinds indexes the objects by group kind and index maintains lookups of the nodes.
+	kinds map[schema.GroupKind][]*unstructured.Unstructured
+	index *nodeIndex
+
+	// deferred are run once all objects are resolved, in the order in which they were added.
+	deferred []deferred
+
+	// mu is held by the workers while they resolve objects and released while objects are retrieved from the source.
+	mu         sync.Mutex
+	concurrent bool
+
+	coreV1              *CoreV1Graph
+	networkingV1        *NetworkingV1Graph
+	routeV1             *RouteV1Graph
+	appsV1              *AppsV1Graph
+	imageV1             *ImageV1Graph
+	buildV1             *BuildV1Graph
+	monitoringV1        *MonitoringV1Graph
+	kedaV1alpha1        *KedaV1alpha1Graph
+	externalSecretsV1   *ExternalSecretsV1Graph
+	bitnamiV1alpha1     *BitnamiV1alpha1Graph
+	kyvernoV1           *KyvernoV1Graph
+	constraintsV1beta1  *ConstraintsV1beta1Graph
+	wgpolicyk8sV1alpha2 *Wgpolicyk8sV1alpha2Graph
+	ciliumV2            *CiliumV2Graph
+	calicoV3            *CalicoV3Graph
+	policyV1alpha1      *PolicyV1alpha1Graph
 }
 
 // Node represents a node in the graph.
 type Node struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Properties        map[string]string `json:"properties,omitempty"`
+	Status            *NodeStatus       `json:"status,omitempty"`
 }
 
 // Relationship represents a relationship between nodes in the graph.
 type Relationship struct {
-	From  types.UID
-	Label string
-	To    types.UID
-	Attr  map[string]string
+	From  types.UID         `json:"from"`
+	Label string            `json:"label"`
+	To    types.UID         `json:"to"`
+	Attr  map[string]string `json:"attributes,omitempty"`
+	// Scope lists the attributes which are part of the identity of the relationship.
+	Scope []string `json:"scope,omitempty"`
+
+	// conflicts records attributes which were set again with a different value.
+	conflicts []string
 }
 
 // Options represents attributes to configure the graph.
 type Options struct {
-	NodeNameLimit int
+	NodeNameLimit int  `json:"nodeNameLimit,omitempty"`
+	Concurrency   int  `json:"concurrency,omitempty"`
+	AllNamespaces bool `json:"allNamespaces,omitempty"`
 }
 
-// ToUID converts all params to MD5 and returns this as types.UID.
+// UIDScheme is the version of the scheme of synthetic UIDs. It is part of every synthetic UID and is only
+// increased when the input of SyntheticUID changes, so UIDs are stable across releases.
+const UIDScheme = "v1"
+
+// ToUID converts all params to MD5 and returns this as types.UID. The params are separated by a NUL byte,
+// which can not occur in the names of Kubernetes objects, so different params never result in the same input.
 func ToUID(params ...interface{}) types.UID {
-	input := make([]string, len(params))
+	input := make([]string, 0, len(params))
 	for _, param := range params {
 		input = append(input, fmt.Sprint(param))
 	}
 
-	bytes := []byte(strings.Join(input, "-"))
+	bytes := []byte(strings.Join(input, "\x00"))
 	md5sum := fmt.Sprintf("%x", md5.Sum(bytes))
 
 	slice := []string{
@@ -138,6 +225,15 @@ func ToUID(params ...interface{}) types.UID {
 	return types.UID(strings.Join(slice, "-"))
 }
 
+// SyntheticUID returns the UID of an object which has no UID of its own, like the nodes of hosts, images and
+// placeholders or the objects of offline manifests. The UID is derived from the UIDScheme, the cluster, the group,
+// the kind, the namespace and the name, but not the version of the object. So the same object always gets the
+// same UID, while objects of different kinds or clusters never collide, even when several clusters are imported
+// into the same database.
+func (g *Graph) SyntheticUID(gvk schema.GroupVersionKind, namespace string, name string) types.UID {
+	return ToUID(UIDScheme, g.cluster, gvk.Group, gvk.Kind, namespace, name)
+}
+
 // FilterByValue filters a key value map by value using a function.
 func FilterByValue(kv map[string]string, f func(string) bool) map[string]string {
 	filtered := make(map[string]string, 0)
@@ -150,8 +246,20 @@ func FilterByValue(kv map[string]string, f func(string) bool) map[string]string
 	return filtered
 }
 
+// FilterByKey filters a key value map by key using a function.
+func FilterByKey(kv map[string]string, f func(string) bool) map[string]string {
+	filtered := make(map[string]string, 0)
+	for key, value := range kv {
+		if f(key) {
+			filtered[key] = value
+		}
+	}
+
+	return filtered
+}
+
 // FromUnstructured converts an unstructured object into a concrete type.
-func FromUnstructured(unstr *unstructured.Unstructured, obj runtime.Object) error {
+func FromUnstructured(unstr *unstructured.Unstructured, obj interface{}) error {
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstr.UnstructuredContent(), obj)
 	if err != nil {
 		return fmt.Errorf("failed to convert %T to %T: %v", unstr, obj, err)
@@ -161,85 +269,344 @@ func FromUnstructured(unstr *unstructured.Unstructured, obj runtime.Object) erro
 }
 
 // NewGraph returns a new initialized a Graph.
-func NewGraph(clientset *kubernetes.Clientset, objs []*unstructured.Unstructured, processed func()) (*Graph, error) {
+//
+// Related objects are retrieved from the given ObjectSource. Without a source all relationships are resolved
+// from the given objects only, which allows to graph manifests without a cluster. Objects without a UID get
+// a synthetic UID based on their kind and name. Objects which can not be resolved are added to the Warnings
+// instead of aborting the Graph.
+//
+// The objects are resolved by a pool of Options.Concurrency workers. All lookups of a run are cached, so
+// repeated lookups of the same objects are served once. The dependencies of the graphed kinds are listed
+// before, once per namespace or once for all namespaces when Options.AllNamespaces is set.
+//
+// All lookups use the given context. When it is canceled or its deadline is exceeded, no further objects are
+// resolved and the partial Graph is returned together with an error.
+func NewGraph(ctx context.Context, source ObjectSource, objs []*unstructured.Unstructured, options *Options, processed func()) (*Graph, error) {
+	if source == nil {
+		source = NewMemorySource(objs)
+	}
+
+	g := newGraph(ctx, source, objs, options)
+	errs := []error{}
+
+	for _, obj := range objs {
+		if obj.GetUID() == "" {
+			obj.SetUID(g.SyntheticUID(obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName()))
+		}
+		g.Node(obj.GroupVersionKind(), obj)
+	}
+
+	if !g.local {
+		g.prefetch(objs)
+	}
+	g.resolve(objs, processed)
+	g.runDeferred()
+
+	err := g.Finalize()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("graph is incomplete: %w", err))
+	}
+
+	return g, errors.NewAggregate(errs)
+}
+
+// newGraph returns a new Graph without any nodes for the given ObjectSource and Options.
+func newGraph(ctx context.Context, source ObjectSource, objs []*unstructured.Unstructured, options *Options) *Graph {
+	if options == nil {
+		options = &Options{}
+	}
+	if options.NodeNameLimit <= 0 {
+		options.NodeNameLimit = DefaultNodeNameLimit
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultConcurrency
+	}
+
+	_, local := source.(*MemorySource)
+
 	g := &Graph{
-		clientset:     clientset,
+		ctx:           ctx,
+		source:        NewCachedSource(source),
+		local:         local,
+		cluster:       source.Cluster(),
+		created:       time.Now().UTC(),
+		objects:       objs,
+		kinds:         make(map[schema.GroupKind][]*unstructured.Unstructured),
+		index:         newNodeIndex(),
 		Nodes:         make(map[types.UID]*Node),
 		Relationships: make(map[types.UID][]*Relationship),
-		Options: &Options{
-			NodeNameLimit: DefaultNodeNameLimit,
-		},
+		Options:       options,
+	}
+
+	for _, obj := range objs {
+		gk := obj.GroupVersionKind().GroupKind()
+		g.kinds[gk] = append(g.kinds[gk], obj)
 	}
 
 	g.coreV1 = NewCoreV1Graph(g)
 	g.networkingV1 = NewNetworkingV1Graph(g)
 	g.routeV1 = NewRouteV1Graph(g)
+	g.appsV1 = NewAppsV1Graph(g)
+	g.imageV1 = NewImageV1Graph(g)
+	g.buildV1 = NewBuildV1Graph(g)
+	g.monitoringV1 = NewMonitoringV1Graph(g)
+	g.kedaV1alpha1 = NewKedaV1alpha1Graph(g)
+	g.externalSecretsV1 = NewExternalSecretsV1Graph(g)
+	g.bitnamiV1alpha1 = NewBitnamiV1alpha1Graph(g)
+	g.kyvernoV1 = NewKyvernoV1Graph(g)
+	g.constraintsV1beta1 = NewConstraintsV1beta1Graph(g)
+	g.wgpolicyk8sV1alpha2 = NewWgpolicyk8sV1alpha2Graph(g)
+	g.ciliumV2 = NewCiliumV2Graph(g)
+	g.calicoV3 = NewCalicoV3Graph(g)
+	g.policyV1alpha1 = NewPolicyV1alpha1Graph(g)
+
+	return g
+}
 
-	errs := []error{}
+// prefetch lists the dependencies of the kinds of all objects with a pool of workers. A list which fails
+// is not reported, because the resolvers fall back to single lookups of the objects.
+func (g *Graph) prefetch(objs []*unstructured.Unstructured) {
+	cached, ok := g.source.(*CachedSource)
+	if !ok {
+		return
+	}
 
+	plan := make(map[schema.GroupVersionKind]map[string]bool)
 	for _, obj := range objs {
-		g.Node(obj.GroupVersionKind(), obj)
+		namespace := obj.GetNamespace()
+		if g.Options.AllNamespaces {
+			namespace = metav1.NamespaceAll
+		}
+
+		for _, dep := range Dependencies(obj.GroupVersionKind()) {
+			if plan[dep] == nil {
+				plan[dep] = make(map[string]bool)
+			}
+			plan[dep][namespace] = true
+		}
+	}
+
+	type job struct {
+		gvk       schema.GroupVersionKind
+		namespace string
+	}
+
+	jobs := make(chan job)
+	wg := sync.WaitGroup{}
+	for i := 0; i < g.Options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				_ = cached.Prefetch(g.ctx, j.gvk, j.namespace)
+			}
+		}()
+	}
+
+	// send returns false when the context is done and no further jobs are sent.
+	send := func(j job) bool {
+		select {
+		case jobs <- j:
+			return true
+		case <-g.ctx.Done():
+			return false
+		}
+	}
+
+dispatch:
+	for gvk, namespaces := range plan {
+		if namespaces[metav1.NamespaceAll] {
+			if !send(job{gvk: gvk, namespace: metav1.NamespaceAll}) {
+				break dispatch
+			}
+			continue
+		}
+		for namespace := range namespaces {
+			if !send(job{gvk: gvk, namespace: namespace}) {
+				break dispatch
+			}
+		}
 	}
+	close(jobs)
+	wg.Wait()
+}
 
+// resolve resolves all objects with a pool of workers. Only one worker at a time mutates the Graph,
+// but the lookups of all workers run concurrently. Afterwards the relationships and warnings are
+// sorted, so the result does not depend on the order in which the workers finished. When the context
+// is done, the objects in progress are finished and all remaining objects are skipped.
+func (g *Graph) resolve(objs []*unstructured.Unstructured, processed func()) {
+	jobs := make(chan *unstructured.Unstructured)
+	wg := sync.WaitGroup{}
+
+	g.concurrent = true
+	for i := 0; i < g.Options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for obj := range jobs {
+				g.mu.Lock()
+				if _, err := g.Unstructured(obj); err != nil && g.ctx.Err() == nil {
+					g.Warn(Warning{Reason: ReasonFailed, Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName(), Err: err})
+				}
+				processed()
+				g.mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
 	for _, obj := range objs {
-		_, err := g.Unstructured(obj)
-		if err != nil {
-			errs = append(errs, err)
+		select {
+		case jobs <- obj:
+		case <-g.ctx.Done():
+			break dispatch
 		}
-		processed()
 	}
+	close(jobs)
+	wg.Wait()
+	g.concurrent = false
 
-	err := g.Finalize()
-	if err != nil {
-		errs = append(errs, err)
+	for _, relationships := range g.Relationships {
+		for _, r := range relationships {
+			g.conflicts(r)
+		}
+		sort.SliceStable(relationships, func(i, j int) bool {
+			if relationships[i].From != relationships[j].From {
+				return relationships[i].From < relationships[j].From
+			}
+			return relationships[i].Label < relationships[j].Label
+		})
 	}
 
-	return g, errors.NewAggregate(errs)
+	sort.SliceStable(g.Warnings, func(i, j int) bool {
+		a, b := g.Warnings[i], g.Warnings[j]
+		return a.Kind+"/"+a.Namespace+"/"+a.Name+"/"+a.Reason < b.Kind+"/"+b.Namespace+"/"+b.Name+"/"+b.Reason
+	})
 }
 
-// Unstructured adds an unstructured node to the Graph.
+// conflicts adds a warning for a relationship with conflicting attributes, which were set by different rules.
+func (g *Graph) conflicts(r *Relationship) {
+	if len(r.Conflicts()) == 0 {
+		return
+	}
+
+	from, to := g.Nodes[r.From], g.Nodes[r.To]
+	if from == nil || to == nil {
+		return
+	}
+
+	err := fmt.Errorf("%s relationship to %s %s has conflicting attributes %s", r.Label, to.Kind, to.Name, strings.Join(r.Conflicts(), ", "))
+	g.Warn(Warning{Reason: ReasonConflict, Kind: from.Kind, Namespace: from.Namespace, Name: from.Name, Err: err})
+}
+
+// deferred is a function of a resolver which is run once all objects are resolved.
+type deferred struct {
+	node *Node
+	f    func() error
+}
+
+// Defer adds a function which is run once all objects are resolved, e.g. to evaluate a policy against all nodes
+// of the Graph including the nodes which were added by other resolvers. An error is added as warning for the node.
+func (g *Graph) Defer(n *Node, f func() error) {
+	g.deferred = append(g.deferred, deferred{node: n, f: f})
+}
+
+// runDeferred runs all deferred functions, unless the context is done.
+func (g *Graph) runDeferred() {
+	sort.SliceStable(g.deferred, func(i, j int) bool {
+		return CompareNodes(g.deferred[i].node, g.deferred[j].node) < 0
+	})
+
+	for _, d := range g.deferred {
+		if g.ctx.Err() != nil {
+			return
+		}
+		if err := d.f(); err != nil {
+			g.Warn(Warning{Reason: ReasonFailed, Kind: d.node.Kind, Namespace: d.node.Namespace, Name: d.node.Name, Err: err})
+		}
+	}
+	g.deferred = nil
+}
+
+// Context returns the context of the run, which should be used by resolvers for their own lookups.
+func (g *Graph) Context() context.Context {
+	return g.ctx
+}
+
+// get retrieves an object from the ObjectSource of the Graph. While objects are resolved,
+// the lock of the Graph is released during the lookup.
+func (g *Graph) get(gvk schema.GroupVersionKind, namespace string, name string) (*unstructured.Unstructured, error) {
+	if g.concurrent {
+		g.mu.Unlock()
+		defer g.mu.Lock()
+	}
+
+	return g.source.Get(g.ctx, gvk, namespace, name)
+}
+
+// list retrieves all objects matching the selector from the ObjectSource of the Graph. While objects
+// are resolved, the lock of the Graph is released during the lookup.
+func (g *Graph) list(gvk schema.GroupVersionKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	if g.concurrent {
+		g.mu.Unlock()
+		defer g.mu.Lock()
+	}
+
+	return g.source.List(g.ctx, gvk, namespace, selector)
+}
+
+// Unstructured adds an unstructured node to the Graph with the Resolver registered for its kind.
+// Objects without a Resolver are added without any relationships except to their owners.
 func (g *Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
-	switch unstr.GetAPIVersion() {
-	case "v1":
-		return g.CoreV1().Unstructured(unstr)
-	case "networking.k8s.io/v1":
-		return g.NetworkingV1().Unstructured(unstr)
-	case "route.openshift.io/v1":
-		return g.RouteV1().Unstructured(unstr)
-	default:
-		return g.Node(unstr.GroupVersionKind(), unstr), nil
+	if r := Lookup(unstr.GroupVersionKind()); r != nil {
+		return r.Resolve(g, unstr)
 	}
+
+	return g.Node(unstr.GroupVersionKind(), unstr), nil
 }
 
-// Node adds a node and the owner references to the Graph.
+// Node adds a node and the owner references to the Graph. An existing node with the same UID is updated in place,
+// so resolvers which hold the node while the lock of the Graph is released never write to a stale node. Its
+// annotations, labels, owner references, properties and status are kept when they are already set.
 func (g *Graph) Node(gvk schema.GroupVersionKind, obj metav1.Object) *Node {
 	apiVersion, kind := gvk.ToAPIVersionAndKind()
-	node := &Node{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: apiVersion,
-			Kind:       kind,
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			UID:       obj.GetUID(),
-			Namespace: obj.GetNamespace(),
-			Name:      obj.GetName(),
-			Annotations: FilterByValue(obj.GetAnnotations(), func(v string) bool {
-				return !strings.HasPrefix(v, "{") && !strings.HasPrefix(v, "[")
-			}),
-			Labels: obj.GetLabels(),
-		},
+
+	node, ok := g.Nodes[obj.GetUID()]
+	if ok {
+		g.index.remove(node)
+	} else {
+		node = &Node{ObjectMeta: metav1.ObjectMeta{UID: obj.GetUID()}}
+		g.Nodes[obj.GetUID()] = node
 	}
 
-	if n, ok := g.Nodes[obj.GetUID()]; ok {
-		if len(n.GetAnnotations()) != 0 {
-			node.SetAnnotations(n.GetAnnotations())
-		}
-		if len(n.GetLabels()) != 0 {
-			node.SetLabels(n.GetLabels())
-		}
+	node.TypeMeta = metav1.TypeMeta{
+		APIVersion: apiVersion,
+		Kind:       kind,
+	}
+	node.SetNamespace(obj.GetNamespace())
+	node.SetName(obj.GetName())
+
+	if len(node.GetAnnotations()) == 0 {
+		node.SetAnnotations(FilterByValue(obj.GetAnnotations(), func(v string) bool {
+			return !strings.HasPrefix(v, "{") && !strings.HasPrefix(v, "[")
+		}))
+	}
+	if len(node.GetLabels()) == 0 {
+		node.SetLabels(obj.GetLabels())
+	}
+	if len(node.GetOwnerReferences()) == 0 {
+		node.SetOwnerReferences(obj.GetOwnerReferences())
+	}
+	if node.Status == nil {
+		node.Status = Status(gvk, obj)
 	}
 
-	g.Nodes[obj.GetUID()] = node
+	g.index.add(node)
 
 	for _, ownerRef := range obj.GetOwnerReferences() {
 		owner := g.Node(
@@ -256,15 +623,38 @@ func (g *Graph) Node(gvk schema.GroupVersionKind, obj metav1.Object) *Node {
 	return node
 }
 
-// FindNode returns a node by identity when it already exists in the graph.
-func (g *Graph) FindNode(apiVersion string, kind string, namespace string, name string) *Node {
-	for _, node := range g.Nodes {
-		if node.APIVersion == apiVersion && node.Kind == kind && node.Namespace == namespace && node.Name == name {
-			return node
+// Ref resolves an existing node or adds a new node with the given kind, namespace and name to the Graph.
+func (g *Graph) Ref(gvk schema.GroupVersionKind, namespace string, name string) *Node {
+	if n := g.FindNode(gvk.GroupVersion().String(), gvk.Kind, namespace, name); n != nil {
+		return n
+	}
+
+	return g.Node(
+		gvk,
+		&metav1.ObjectMeta{
+			UID:       g.SyntheticUID(gvk, namespace, name),
+			Namespace: namespace,
+			Name:      name,
+		},
+	)
+}
+
+// Objects returns all objects which were passed to the Graph by group and kind, filtered by namespace and labels.
+// An empty namespace returns the objects of all namespaces.
+func (g *Graph) Objects(gk schema.GroupKind, namespace string, selector labels.Selector) []*unstructured.Unstructured {
+	objs := []*unstructured.Unstructured{}
+
+	for _, obj := range g.kinds[gk] {
+		if namespace != metav1.NamespaceAll && obj.GetNamespace() != namespace {
+			continue
 		}
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		objs = append(objs, obj)
 	}
 
-	return nil
+	return objs
 }
 
 // Finalize adds missing relationships to the Graph.
@@ -299,7 +689,7 @@ func (g *Graph) Finalize() error {
 	return nil
 }
 
-// NodeList returns a list of all nodes.
+// NodeList returns a list of all nodes sorted by kind, namespace and name.
 func (g *Graph) NodeList() []*Node {
 	nodes := []*Node{}
 
@@ -307,16 +697,58 @@ func (g *Graph) NodeList() []*Node {
 		nodes = append(nodes, node)
 	}
 
+	sort.Slice(nodes, func(i, j int) bool {
+		return CompareNodes(nodes[i], nodes[j]) < 0
+	})
+
 	return nodes
 }
 
-// Relationship creates a new relationship between two nodes.
+// CompareNodes orders nodes by kind, namespace, name, apiVersion and finally UID, so the order is stable across runs.
+func CompareNodes(a *Node, b *Node) int {
+	keys := [][2]string{
+		{a.Kind, b.Kind},
+		{a.Namespace, b.Namespace},
+		{a.Name, b.Name},
+		{a.APIVersion, b.APIVersion},
+		{string(a.UID), string(b.UID)},
+	}
+	for _, key := range keys {
+		if c := strings.Compare(key[0], key[1]); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// Synthetic returns true when the node does not represent a Kubernetes object, like containers, hosts or images.
+func (n *Node) Synthetic() bool {
+	return syntheticKinds[n.Kind] || n.APIVersion == "kubectl-graph/v1"
+}
+
+// Property sets a property on the node which is not part of its metadata.
+func (n *Node) Property(key string, value string) *Node {
+	if n.Properties == nil {
+		n.Properties = map[string]string{}
+	}
+	n.Properties[key] = value
+	return n
+}
+
+// Relationship creates a new relationship between two nodes or returns the existing one with the same label.
+// Two nodes can have several relationships with different labels.
 func (g *Graph) Relationship(from *Node, label string, to *Node) *Relationship {
-	if rs, ok := g.Relationships[to.GetUID()]; ok {
-		for _, r := range rs {
-			if r.From == from.GetUID() {
-				return r
-			}
+	return g.ScopedRelationship(from, label, to, nil)
+}
+
+// ScopedRelationship creates a new relationship between two nodes or returns the existing one with the same label
+// and the same scoped attributes. Scoped attributes describe a single rule, like the action of a network policy
+// rule, so rules with different scoped attributes are kept as separate relationships between the same nodes.
+func (g *Graph) ScopedRelationship(from *Node, label string, to *Node, scope map[string]string) *Relationship {
+	for _, r := range g.Relationships[to.GetUID()] {
+		if r.From == from.GetUID() && r.Label == label && r.scoped(scope) {
+			return r
 		}
 	}
 
@@ -326,12 +758,31 @@ func (g *Graph) Relationship(from *Node, label string, to *Node) *Relationship {
 		To:    to.GetUID(),
 		Attr:  make(map[string]string),
 	}
+	for key, value := range scope {
+		relationship.Scope = append(relationship.Scope, key)
+		relationship.Attr[key] = value
+	}
+	sort.Strings(relationship.Scope)
 	g.Relationships[to.GetUID()] = append(g.Relationships[to.GetUID()], relationship)
 
 	return relationship
 }
 
-// RelationshipList returns a list of all relationships.
+// scoped returns true when the relationship has exactly the given scoped attributes.
+func (r *Relationship) scoped(scope map[string]string) bool {
+	if len(r.Scope) != len(scope) {
+		return false
+	}
+	for _, key := range r.Scope {
+		if value, ok := scope[key]; !ok || r.Attr[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RelationshipList returns a list of all relationships sorted by their source node, label, target node and scoped attributes.
 func (g *Graph) RelationshipList() []*Relationship {
 	relationships := []*Relationship{}
 
@@ -339,15 +790,109 @@ func (g *Graph) RelationshipList() []*Relationship {
 		relationships = append(relationships, relationship...)
 	}
 
+	node := func(uid types.UID) *Node {
+		if n, ok := g.Nodes[uid]; ok {
+			return n
+		}
+		return &Node{ObjectMeta: metav1.ObjectMeta{UID: uid}}
+	}
+
+	sort.Slice(relationships, func(i, j int) bool {
+		a, b := relationships[i], relationships[j]
+		if c := CompareNodes(node(a.From), node(b.From)); c != 0 {
+			return c < 0
+		}
+		if a.Label != b.Label {
+			return a.Label < b.Label
+		}
+		if c := CompareNodes(node(a.To), node(b.To)); c != 0 {
+			return c < 0
+		}
+		return a.scopeKey() < b.scopeKey()
+	})
+
 	return relationships
 }
 
-// Attribute adds an attribute to a relationship.
+// Attribute adds an attribute to a relationship. Style attributes are replaced and the comma separated values of
+// set attributes are merged into a sorted list of distinct values, where a wildcard absorbs all values it covers.
+// Any other attribute keeps its first value and a different value is recorded as a conflict of the relationship.
 func (r *Relationship) Attribute(key string, value string) *Relationship {
-	r.Attr[key] = value
+	existing, ok := r.Attr[key]
+	switch {
+	case !ok || existing == value || styleAttributes[key]:
+		r.Attr[key] = value
+	case setAttributes[key]:
+		r.Attr[key] = strings.Join(MergeValues(strings.Split(existing, ", "), strings.Split(value, ", ")), ", ")
+	default:
+		r.conflicts = append(r.conflicts, fmt.Sprintf("%s: %q and %q", key, existing, value))
+	}
+
 	return r
 }
 
+// MergeValues returns the sorted distinct values of both lists. A value "*" absorbs all other values and
+// a value with the suffix "/*", like "TCP/*", absorbs all values with the same prefix, like "TCP/443".
+func MergeValues(a []string, b []string) []string {
+	distinct := map[string]bool{}
+	for _, v := range append(a, b...) {
+		distinct[v] = true
+	}
+
+	if distinct["*"] {
+		return []string{"*"}
+	}
+
+	values := []string{}
+	for v := range distinct {
+		if prefix, _, ok := strings.Cut(v, "/"); ok && !strings.HasSuffix(v, "/*") && distinct[prefix+"/*"] {
+			continue
+		}
+		values = append(values, v)
+	}
+	sort.Strings(values)
+
+	return values
+}
+
+// Conflicts returns all attributes which were set again with a different value than their first value.
+func (r *Relationship) Conflicts() []string {
+	return r.conflicts
+}
+
+// Key returns a stable key of the relationship which is unique for its source node, label, target node and scoped attributes.
+func (r *Relationship) Key() types.UID {
+	if len(r.Scope) == 0 {
+		return ToUID(UIDScheme, r.From, r.Label, r.To)
+	}
+
+	return ToUID(UIDScheme, r.From, r.Label, r.To, r.scopeKey())
+}
+
+// scopeKey returns the scoped attributes of the relationship as a single string.
+func (r *Relationship) scopeKey() string {
+	scope := []string{}
+	for _, key := range r.Scope {
+		scope = append(scope, key+"="+r.Attr[key])
+	}
+
+	return strings.Join(scope, "\x00")
+}
+
+// Style returns all attributes which only affect how the relationship is drawn.
+func (r *Relationship) Style() map[string]string {
+	return FilterByKey(r.Attr, func(k string) bool {
+		return styleAttributes[k]
+	})
+}
+
+// Properties returns all attributes which describe the relationship itself.
+func (r *Relationship) Properties() map[string]string {
+	return FilterByKey(r.Attr, func(k string) bool {
+		return !styleAttributes[k]
+	})
+}
+
 // String returns the graph in requested format.
 func (g *Graph) String(format string) string {
 	b := &bytes.Buffer{}
@@ -356,7 +901,11 @@ func (g *Graph) String(format string) string {
 	return b.String()
 }
 
-// Write formats according to the requested format and writes to w.
+// Write formats according to the requested format and writes to w. The json format writes a Snapshot.
 func (g *Graph) Write(w io.Writer, format string) error {
+	if format == "json" {
+		return g.WriteSnapshot(w)
+	}
+
 	return templates.ExecuteTemplate(w, format+".tmpl", g)
 }
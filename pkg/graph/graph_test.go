@@ -0,0 +1,239 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// testObjects parses the YAML documents of the manifests, which are separated by "---" lines.
+func testObjects(t *testing.T, manifests string) []*unstructured.Unstructured {
+	t.Helper()
+
+	objs := []*unstructured.Unstructured{}
+	for _, doc := range strings.Split(strings.TrimSpace(manifests), "\n---\n") {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(doc), &obj.Object); err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs, obj)
+	}
+
+	return objs
+}
+
+// testEdges returns the relationships of the Graph as "Kind/name Label Kind/name" followed by their
+// sorted properties, in the order of RelationshipList.
+func testEdges(g *Graph) []string {
+	name := func(n *Node) string {
+		if n == nil {
+			return "<nil>"
+		}
+		return n.Kind + "/" + n.Name
+	}
+
+	edges := []string{}
+	for _, r := range g.RelationshipList() {
+		edge := fmt.Sprintf("%s %s %s", name(g.Nodes[r.From]), r.Label, name(g.Nodes[r.To]))
+
+		attributes := []string{}
+		for key, value := range r.Properties() {
+			attributes = append(attributes, key+"="+value)
+		}
+		sort.Strings(attributes)
+		if len(attributes) > 0 {
+			edge += " " + strings.Join(attributes, " ")
+		}
+
+		edges = append(edges, edge)
+	}
+
+	return edges
+}
+
+func TestMergeValues(t *testing.T) {
+	tests := []struct {
+		a    []string
+		b    []string
+		want []string
+	}{
+		{a: []string{"TCP/80"}, b: []string{"TCP/443"}, want: []string{"TCP/443", "TCP/80"}},
+		{a: []string{"TCP/80", "UDP/53"}, b: []string{"TCP/80"}, want: []string{"TCP/80", "UDP/53"}},
+		{a: []string{"TCP/80"}, b: []string{"*"}, want: []string{"*"}},
+		{a: []string{"TCP/80", "UDP/53"}, b: []string{"TCP/*"}, want: []string{"TCP/*", "UDP/53"}},
+		{a: []string{"TCP/*"}, b: []string{"TCP/8080-8090"}, want: []string{"TCP/*"}},
+	}
+
+	for _, tt := range tests {
+		if got := MergeValues(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MergeValues(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	r := &Relationship{Attr: map[string]string{}}
+
+	r.Attribute("ports", "TCP/80").Attribute("ports", "UDP/53, TCP/443").Attribute("ports", "TCP/80")
+	if got, want := r.Attr["ports"], "TCP/443, TCP/80, UDP/53"; got != want {
+		t.Errorf("merged ports = %q, want %q", got, want)
+	}
+
+	r.Attribute("color", "#000000").Attribute("color", "#ffffff")
+	if got, want := r.Attr["color"], "#ffffff"; got != want {
+		t.Errorf("style attribute = %q, want %q", got, want)
+	}
+
+	r.Attribute("path", "/api").Attribute("path", "/api").Attribute("path", "/admin")
+	if got, want := r.Attr["path"], "/api"; got != want {
+		t.Errorf("conflicting attribute = %q, want the first value %q", got, want)
+	}
+	if got, want := r.Conflicts(), []string{`path: "/api" and "/admin"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("conflicts = %q, want %q", got, want)
+	}
+}
+
+func TestScopedRelationship(t *testing.T) {
+	g := newGraph(context.Background(), NewMemorySource(nil), nil, &Options{})
+	from := g.Ref(networkingv1.SchemeGroupVersion.WithKind("IPBlock"), "", "10.0.0.0/8")
+	to := g.Ref(networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy"), "shop", "api")
+
+	allow := g.ScopedRelationship(from, "Ingress", to, map[string]string{"action": "Allow"}).Attribute("ports", "TCP/80")
+	deny := g.ScopedRelationship(from, "Ingress", to, map[string]string{"action": "Deny"}).Attribute("ports", "TCP/22")
+	unscoped := g.Relationship(from, "Ingress", to)
+
+	if again := g.ScopedRelationship(from, "Ingress", to, map[string]string{"action": "Allow"}).Attribute("ports", "TCP/443"); again != allow {
+		t.Error("a relationship with the same scope is not reused")
+	}
+	if allow == deny || allow == unscoped || deny == unscoped {
+		t.Error("relationships with different scopes are not kept apart")
+	}
+	if len(g.Relationships[to.UID]) != 3 {
+		t.Errorf("graph has %d relationships, want 3", len(g.Relationships[to.UID]))
+	}
+
+	if got, want := allow.Attr, map[string]string{"action": "Allow", "ports": "TCP/443, TCP/80"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("allow attributes = %v, want %v", got, want)
+	}
+	if got, want := deny.Attr, map[string]string{"action": "Deny", "ports": "TCP/22"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("deny attributes = %v, want %v", got, want)
+	}
+	if allow.Key() == deny.Key() || allow.Key() == unscoped.Key() {
+		t.Error("relationships with different scopes have the same key")
+	}
+	if got, want := unscoped.Key(), ToUID(UIDScheme, from.UID, "Ingress", to.UID); got != want {
+		t.Errorf("key of an unscoped relationship = %s, want %s", got, want)
+	}
+}
+
+func TestConflictWarning(t *testing.T) {
+	objs := testObjects(t, `
+apiVersion: route.openshift.io/v1
+kind: Route
+metadata:
+  name: web
+  namespace: shop
+  uid: route-web
+spec:
+  to:
+    kind: Service
+    name: web
+    weight: 80
+  alternateBackends:
+    - kind: Service
+      name: web
+      weight: 20
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+  uid: service-web
+`)
+
+	g, err := NewGraph(context.Background(), nil, objs, &Options{Concurrency: 1}, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g.Warnings) != 1 {
+		t.Fatalf("warnings = %v, want a single conflict", g.Warnings)
+	}
+	w := g.Warnings[0]
+	if w.Reason != ReasonConflict || w.Kind != "Route" || w.Namespace != "shop" || w.Name != "web" {
+		t.Errorf("warning = %s, want a conflict of the route", w)
+	}
+	if want := `Route relationship to Service web has conflicting attributes weight: "80" and "20"`; w.Err == nil || w.Err.Error() != want {
+		t.Errorf("warning error = %v, want %s", w.Err, want)
+	}
+}
+
+func TestSyntheticUID(t *testing.T) {
+	// The UIDs are pinned, because a change of the scheme would change the UID of every exported node.
+	// UIDScheme must be increased when they change.
+	tests := []struct {
+		cluster   string
+		gvk       schema.GroupVersionKind
+		namespace string
+		name      string
+		want      types.UID
+	}{
+		{
+			cluster:   "local",
+			gvk:       corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+			namespace: "shop",
+			name:      "settings",
+			want:      "c89ff838-6e32-c32b-761b-72c83bea0eb0",
+		},
+		{
+			cluster: "prod.example.com",
+			gvk:     networkingv1.SchemeGroupVersion.WithKind("Host"),
+			name:    "shop.example.com",
+			want:    "d02c0300-caae-48f0-822e-1c3aea373939",
+		},
+		{
+			cluster: "prod.example.com",
+			gvk:     schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Host"},
+			name:    "shop.example.com",
+			want:    "d02c0300-caae-48f0-822e-1c3aea373939",
+		},
+	}
+
+	if UIDScheme != "v1" {
+		t.Fatalf("UIDScheme = %s, update the pinned UIDs of this test", UIDScheme)
+	}
+
+	for _, tt := range tests {
+		g := newGraph(context.Background(), NewMemorySource(nil), nil, &Options{})
+		g.cluster = tt.cluster
+
+		if got := g.SyntheticUID(tt.gvk, tt.namespace, tt.name); got != tt.want {
+			t.Errorf("SyntheticUID(%s, %q, %q) in %s = %s, want %s", tt.gvk, tt.namespace, tt.name, tt.cluster, got, tt.want)
+		}
+	}
+}
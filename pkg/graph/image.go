@@ -0,0 +1,163 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/openshift/api/image/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	Register(v1.SchemeGroupVersion.WithKind("ImageStream"), Typed((*Graph).ImageV1, (*ImageV1Graph).ImageStream))
+	Register(v1.SchemeGroupVersion.WithKind("ImageStreamTag"), Typed((*Graph).ImageV1, (*ImageV1Graph).ImageStreamTag))
+}
+
+// ImageV1Graph is used to graph all OpenShift image resources.
+type ImageV1Graph struct {
+	graph *Graph
+}
+
+// NewImageV1Graph creates a new ImageV1Graph.
+func NewImageV1Graph(g *Graph) *ImageV1Graph {
+	return &ImageV1Graph{
+		graph: g,
+	}
+}
+
+// ImageV1 retrieves the ImageV1Graph.
+func (g *Graph) ImageV1() *ImageV1Graph {
+	return g.imageV1
+}
+
+// ImageStream adds a v1.ImageStream resource to the Graph.
+func (g *ImageV1Graph) ImageStream(obj *v1.ImageStream) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	for _, tag := range obj.Spec.Tags {
+		if tag.From == nil {
+			continue
+		}
+
+		from, err := g.ObjectReference(tag.From, obj.GetNamespace())
+		if err != nil {
+			return nil, err
+		}
+		if from == nil || from.UID == n.UID {
+			continue
+		}
+		g.graph.Relationship(n, "Tag", from).Attribute("tag", tag.Name)
+	}
+
+	return n, nil
+}
+
+// ImageStreamTag adds a v1.ImageStreamTag resource to the Graph.
+func (g *ImageV1Graph) ImageStreamTag(obj *v1.ImageStreamTag) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	name, _, _ := strings.Cut(obj.GetName(), ":")
+	stream, err := g.ImageStreamRef(obj.GetNamespace(), name)
+	if err != nil {
+		return nil, err
+	}
+	g.graph.Relationship(stream, "ImageStreamTag", n)
+
+	if obj.Image.DockerImageReference != "" {
+		image, err := g.graph.CoreV1().Image(obj.Image.DockerImageReference)
+		if err != nil {
+			return nil, err
+		}
+		g.graph.Relationship(n, "Image", image)
+	}
+
+	return n, nil
+}
+
+// ObjectReference adds the image referenced by a build or trigger to the Graph.
+// It returns nil for kinds which do not refer to an image.
+func (g *ImageV1Graph) ObjectReference(ref *corev1.ObjectReference, namespace string) (*Node, error) {
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+
+	switch ref.Kind {
+	case "ImageStreamTag":
+		return g.ImageStreamTagRef(namespace, ref.Name)
+	case "ImageStreamImage":
+		name, _, _ := strings.Cut(ref.Name, "@")
+		return g.ImageStreamRef(namespace, name)
+	case "ImageStream":
+		return g.ImageStreamRef(namespace, ref.Name)
+	case "DockerImage":
+		return g.graph.CoreV1().Image(ref.Name)
+	}
+
+	return nil, nil
+}
+
+// ImageStreamRef adds a v1.ImageStream resource to the Graph or resolves an existing node for it.
+func (g *ImageV1Graph) ImageStreamRef(namespace string, name string) (*Node, error) {
+	if name == "" {
+		return nil, fmt.Errorf("imagestream reference is missing a name")
+	}
+
+	if n := g.graph.FindNode(v1.SchemeGroupVersion.String(), "ImageStream", namespace, name); n != nil {
+		return n, nil
+	}
+
+	n := g.graph.Node(
+		v1.SchemeGroupVersion.WithKind("ImageStream"),
+		&metav1.ObjectMeta{
+			UID:       g.graph.SyntheticUID(v1.SchemeGroupVersion.WithKind("ImageStream"), namespace, name),
+			Namespace: namespace,
+			Name:      name,
+		},
+	)
+
+	return n, nil
+}
+
+// ImageStreamTagRef adds a v1.ImageStreamTag resource and its image stream to the Graph or resolves an existing node for it.
+func (g *ImageV1Graph) ImageStreamTagRef(namespace string, name string) (*Node, error) {
+	if name == "" {
+		return nil, fmt.Errorf("imagestreamtag reference is missing a name")
+	}
+
+	if n := g.graph.FindNode(v1.SchemeGroupVersion.String(), "ImageStreamTag", namespace, name); n != nil {
+		return n, nil
+	}
+
+	n := g.graph.Node(
+		v1.SchemeGroupVersion.WithKind("ImageStreamTag"),
+		&metav1.ObjectMeta{
+			UID:       g.graph.SyntheticUID(v1.SchemeGroupVersion.WithKind("ImageStreamTag"), namespace, name),
+			Namespace: namespace,
+			Name:      name,
+		},
+	)
+
+	stream, _, _ := strings.Cut(name, ":")
+	s, err := g.ImageStreamRef(namespace, stream)
+	if err != nil {
+		return nil, err
+	}
+	g.graph.Relationship(s, "ImageStreamTag", n)
+
+	return n, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// nodeKey identifies a node by its apiVersion, kind, namespace and name.
+type nodeKey struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+}
+
+// nodeSet is a set of nodes by UID.
+type nodeSet map[types.UID]*Node
+
+// nodeIndex maintains lookups of the nodes of a Graph by identity, group kind and labels.
+// It is updated by Graph.Node, so all nodes must be added through it.
+type nodeIndex struct {
+	identities map[nodeKey]*Node
+	kinds      map[schema.GroupKind]nodeSet
+	labels     map[string]map[string]nodeSet
+}
+
+// newNodeIndex returns an empty nodeIndex.
+func newNodeIndex() *nodeIndex {
+	return &nodeIndex{
+		identities: make(map[nodeKey]*Node),
+		kinds:      make(map[schema.GroupKind]nodeSet),
+		labels:     make(map[string]map[string]nodeSet),
+	}
+}
+
+// keyOf returns the identity of the node.
+func keyOf(node *Node) nodeKey {
+	return nodeKey{apiVersion: node.APIVersion, kind: node.Kind, namespace: node.Namespace, name: node.Name}
+}
+
+// add adds the node to all indexes.
+func (i *nodeIndex) add(node *Node) {
+	i.identities[keyOf(node)] = node
+
+	gk := node.GroupVersionKind().GroupKind()
+	if i.kinds[gk] == nil {
+		i.kinds[gk] = make(nodeSet)
+	}
+	i.kinds[gk][node.UID] = node
+
+	for key, value := range node.GetLabels() {
+		if i.labels[key] == nil {
+			i.labels[key] = make(map[string]nodeSet)
+		}
+		if i.labels[key][value] == nil {
+			i.labels[key][value] = make(nodeSet)
+		}
+		i.labels[key][value][node.UID] = node
+	}
+}
+
+// remove removes the node from all indexes.
+func (i *nodeIndex) remove(node *Node) {
+	key := keyOf(node)
+	if n, ok := i.identities[key]; ok && n.UID == node.UID {
+		delete(i.identities, key)
+	}
+
+	delete(i.kinds[node.GroupVersionKind().GroupKind()], node.UID)
+
+	for key, value := range node.GetLabels() {
+		delete(i.labels[key][value], node.UID)
+	}
+}
+
+// candidates returns the smallest set of nodes which can match the selector, based on its
+// equality, set and existence requirements. Otherwise all nodes of the group kind are returned.
+func (i *nodeIndex) candidates(gk schema.GroupKind, selector labels.Selector) nodeSet {
+	best := i.kinds[gk]
+
+	requirements, selectable := selector.Requirements()
+	if !selectable {
+		return nil
+	}
+
+	for _, r := range requirements {
+		values := []string{}
+		switch r.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			values = r.Values().List()
+		case selection.Exists:
+			for value := range i.labels[r.Key()] {
+				values = append(values, value)
+			}
+		default:
+			continue
+		}
+
+		set := make(nodeSet)
+		for _, value := range values {
+			for uid, node := range i.labels[r.Key()][value] {
+				set[uid] = node
+			}
+		}
+		if len(set) < len(best) {
+			best = set
+		}
+	}
+
+	return best
+}
+
+// FindNode returns a node by identity when it already exists in the graph.
+func (g *Graph) FindNode(apiVersion string, kind string, namespace string, name string) *Node {
+	return g.index.identities[nodeKey{apiVersion: apiVersion, kind: kind, namespace: namespace, name: name}]
+}
+
+// NodesByKind returns all nodes of the group kind in the namespace, sorted by namespace and name.
+// An empty namespace returns the nodes of all namespaces.
+func (g *Graph) NodesByKind(gk schema.GroupKind, namespace string) []*Node {
+	return g.MatchNodes(gk, namespace, labels.Everything())
+}
+
+// MatchNodes returns all nodes of the group kind in the namespace whose labels match the selector,
+// sorted by namespace and name. An empty namespace returns the nodes of all namespaces.
+func (g *Graph) MatchNodes(gk schema.GroupKind, namespace string, selector labels.Selector) []*Node {
+	nodes := []*Node{}
+
+	for _, node := range g.index.candidates(gk, selector) {
+		if node.GroupVersionKind().GroupKind() != gk {
+			continue
+		}
+		if namespace != metav1.NamespaceAll && node.Namespace != namespace {
+			continue
+		}
+		if !selector.Matches(labels.Set(node.GetLabels())) {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Namespace != nodes[j].Namespace {
+			return nodes[i].Namespace < nodes[j].Namespace
+		}
+		if nodes[i].Name != nodes[j].Name {
+			return nodes[i].Name < nodes[j].Name
+		}
+		return nodes[i].UID < nodes[j].UID
+	})
+
+	return nodes
+}
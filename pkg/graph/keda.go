@@ -0,0 +1,242 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"crypto/md5"
+	"fmt"
+
+	"github.com/steveteuber/kubectl-graph/pkg/apis/keda/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// kedaNamespace is the namespace in which KEDA resolves the secrets of a ClusterTriggerAuthentication.
+const kedaNamespace = "keda"
+
+// kedaTriggerSources maps a KEDA scaler type to the node kind and the metadata keys which name its event source.
+var kedaTriggerSources = map[string]struct {
+	kind string
+	keys []string
+}{
+	"kafka":            {"KafkaTopic", []string{"topic"}},
+	"rabbitmq":         {"Queue", []string{"queueName"}},
+	"aws-sqs-queue":    {"Queue", []string{"queueURL"}},
+	"azure-queue":      {"Queue", []string{"queueName"}},
+	"azure-servicebus": {"Queue", []string{"queueName", "topicName"}},
+	"gcp-pubsub":       {"Queue", []string{"subscriptionName", "topicName"}},
+	"activemq":         {"Queue", []string{"destinationName"}},
+	"artemis-queue":    {"Queue", []string{"queueName"}},
+	"ibmmq":            {"Queue", []string{"queueName"}},
+	"redis":            {"Queue", []string{"listName"}},
+	"redis-streams":    {"Queue", []string{"stream"}},
+	"nats-jetstream":   {"Queue", []string{"stream"}},
+	"prometheus":       {"PrometheusQuery", []string{"query"}},
+	"cron":             {"Schedule", []string{"start"}},
+	"cpu":              {},
+	"memory":           {},
+}
+
+// kedaQuerySources are the KEDA scaler types whose event source is a query. The query may contain any characters,
+// so the node is named after a hash of the query and the query itself is kept as property of the node.
+var kedaQuerySources = map[string]bool{
+	"prometheus": true,
+}
+
+func init() {
+	Register(v1alpha1.SchemeGroupVersion.WithKind("ScaledObject"), Typed((*Graph).KedaV1alpha1, (*KedaV1alpha1Graph).ScaledObject))
+	Register(v1alpha1.SchemeGroupVersion.WithKind("ScaledJob"), Typed((*Graph).KedaV1alpha1, (*KedaV1alpha1Graph).ScaledJob))
+	Register(v1alpha1.SchemeGroupVersion.WithKind("TriggerAuthentication"), Typed((*Graph).KedaV1alpha1, (*KedaV1alpha1Graph).TriggerAuthentication))
+	Register(v1alpha1.SchemeGroupVersion.WithKind("ClusterTriggerAuthentication"), Typed((*Graph).KedaV1alpha1, (*KedaV1alpha1Graph).ClusterTriggerAuthentication))
+}
+
+// KedaV1alpha1Graph is used to graph all KEDA resources.
+type KedaV1alpha1Graph struct {
+	graph *Graph
+}
+
+// NewKedaV1alpha1Graph creates a new KedaV1alpha1Graph.
+func NewKedaV1alpha1Graph(g *Graph) *KedaV1alpha1Graph {
+	return &KedaV1alpha1Graph{
+		graph: g,
+	}
+}
+
+// KedaV1alpha1 retrieves the KedaV1alpha1Graph.
+func (g *Graph) KedaV1alpha1() *KedaV1alpha1Graph {
+	return g.kedaV1alpha1
+}
+
+// ScaledObject adds a v1alpha1.ScaledObject resource to the Graph.
+func (g *KedaV1alpha1Graph) ScaledObject(obj *v1alpha1.ScaledObject) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if obj.Spec.ScaleTargetRef != nil {
+		t, err := g.ScaleTarget(obj.GetNamespace(), obj.Spec.ScaleTargetRef)
+		if err != nil {
+			return nil, err
+		}
+		g.graph.Relationship(n, "ScaleTarget", t)
+	}
+
+	return n, g.Triggers(n, obj.GetNamespace(), obj.Spec.Triggers)
+}
+
+// ScaledJob adds a v1alpha1.ScaledJob resource and the references of its job template to the Graph.
+func (g *KedaV1alpha1Graph) ScaledJob(obj *v1alpha1.ScaledJob) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if obj.Spec.JobTargetRef != nil {
+		if err := g.graph.CoreV1().PodSpec(n, obj.GetNamespace(), &obj.Spec.JobTargetRef.Template.Spec); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, g.Triggers(n, obj.GetNamespace(), obj.Spec.Triggers)
+}
+
+// TriggerAuthentication adds a v1alpha1.TriggerAuthentication resource to the Graph.
+func (g *KedaV1alpha1Graph) TriggerAuthentication(obj *v1alpha1.TriggerAuthentication) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.TriggerAuthenticationSpec(n, obj.GetNamespace(), obj.Spec)
+}
+
+// ClusterTriggerAuthentication adds a v1alpha1.ClusterTriggerAuthentication resource to the Graph.
+func (g *KedaV1alpha1Graph) ClusterTriggerAuthentication(obj *v1alpha1.ClusterTriggerAuthentication) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.TriggerAuthenticationSpec(n, kedaNamespace, obj.Spec)
+}
+
+// TriggerAuthenticationSpec adds the secrets and config maps of a trigger authentication to the Graph.
+func (g *KedaV1alpha1Graph) TriggerAuthenticationSpec(n *Node, namespace string, spec v1alpha1.TriggerAuthenticationSpec) error {
+	for _, ref := range spec.SecretTargetRef {
+		s, err := g.graph.CoreV1().Secret(namespace, ref.Name)
+		if err != nil {
+			return err
+		}
+		g.graph.Relationship(n, "Secret", s).Attribute("parameter", ref.Parameter)
+	}
+
+	for _, ref := range spec.ConfigMapTargetRef {
+		c, err := g.graph.CoreV1().ConfigMap(namespace, ref.Name)
+		if err != nil {
+			return err
+		}
+		g.graph.Relationship(n, "ConfigMap", c).Attribute("parameter", ref.Parameter)
+	}
+
+	return nil
+}
+
+// Triggers adds the event sources and trigger authentications of all triggers to the Graph.
+func (g *KedaV1alpha1Graph) Triggers(n *Node, namespace string, triggers []v1alpha1.ScaleTriggers) error {
+	for _, trigger := range triggers {
+		t, err := g.Trigger(trigger)
+		if err != nil {
+			return err
+		}
+		if t != nil {
+			g.graph.Relationship(n, "Trigger", t).Attribute("type", trigger.Type)
+		}
+
+		if trigger.AuthenticationRef != nil {
+			a, err := g.TriggerAuthenticationRef(namespace, trigger.AuthenticationRef)
+			if err != nil {
+				return err
+			}
+			g.graph.Relationship(n, a.Kind, a)
+		}
+	}
+
+	return nil
+}
+
+// Trigger adds the event source of a trigger to the Graph. It returns nil for resource metrics like cpu and memory.
+func (g *KedaV1alpha1Graph) Trigger(trigger v1alpha1.ScaleTriggers) (*Node, error) {
+	kind, name := "Trigger", trigger.Name
+	if name == "" {
+		name = trigger.Type
+	}
+
+	if source, ok := kedaTriggerSources[trigger.Type]; ok {
+		if source.kind == "" {
+			return nil, nil
+		}
+
+		for _, key := range source.keys {
+			if value := trigger.Metadata[key]; value != "" {
+				kind, name = source.kind, value
+				break
+			}
+		}
+	}
+
+	gvk := schema.FromAPIVersionAndKind("kubectl-graph/v1", kind)
+	uid := g.graph.SyntheticUID(gvk, "", trigger.Type+"/"+name)
+
+	query := ""
+	if kedaQuerySources[trigger.Type] && kind != "Trigger" {
+		query, name = name, fmt.Sprintf("query-%x", md5.Sum([]byte(name)))[:14]
+	}
+
+	n := g.graph.Node(
+		gvk,
+		&metav1.ObjectMeta{
+			UID:  uid,
+			Name: name,
+		},
+	)
+	if query != "" {
+		n.Property("query", query)
+	}
+
+	return n, nil
+}
+
+// ScaleTarget adds the workload of a v1alpha1.ScaleTarget to the Graph or resolves an existing node for it.
+func (g *KedaV1alpha1Graph) ScaleTarget(namespace string, ref *v1alpha1.ScaleTarget) (*Node, error) {
+	if ref.Name == "" {
+		return nil, fmt.Errorf("scale target reference is missing a name")
+	}
+
+	// KEDA defaults the apiVersion of a scale target to apps/v1, even when the kind is set.
+	apiVersion := ref.APIVersion
+	if apiVersion == "" {
+		apiVersion = appsv1.SchemeGroupVersion.String()
+	}
+
+	gvk := appsv1.SchemeGroupVersion.WithKind("Deployment")
+	if ref.Kind != "" {
+		gvk = schema.FromAPIVersionAndKind(apiVersion, ref.Kind)
+	}
+
+	return g.graph.Ref(gvk, namespace, ref.Name), nil
+}
+
+// TriggerAuthenticationRef adds the trigger authentication of a v1alpha1.AuthenticationRef to the Graph or resolves an existing node for it.
+func (g *KedaV1alpha1Graph) TriggerAuthenticationRef(namespace string, ref *v1alpha1.AuthenticationRef) (*Node, error) {
+	if ref.Name == "" {
+		return nil, fmt.Errorf("trigger authentication reference is missing a name")
+	}
+
+	if ref.Kind == "ClusterTriggerAuthentication" {
+		return g.graph.Ref(v1alpha1.SchemeGroupVersion.WithKind(ref.Kind), metav1.NamespaceNone, ref.Name), nil
+	}
+
+	return g.graph.Ref(v1alpha1.SchemeGroupVersion.WithKind("TriggerAuthentication"), namespace, ref.Name), nil
+}
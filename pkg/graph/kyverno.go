@@ -0,0 +1,266 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"path"
+	"strings"
+
+	v1 "github.com/steveteuber/kubectl-graph/pkg/apis/kyverno/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// kyvernoAutogenAnnotation is the policy annotation which lists the pod controllers that Pod rules are generated for.
+const kyvernoAutogenAnnotation = "pod-policies.kyverno.io/autogen-controllers"
+
+// kyvernoAutogenControllers are the pod controllers that Pod rules are generated for by default.
+var kyvernoAutogenControllers = []string{"DaemonSet", "Deployment", "Job", "StatefulSet", "ReplicaSet", "ReplicationController", "CronJob"}
+
+func init() {
+	Register(v1.SchemeGroupVersion.WithKind("ClusterPolicy"), Typed((*Graph).KyvernoV1, (*KyvernoV1Graph).ClusterPolicy))
+	Register(v1.SchemeGroupVersion.WithKind("Policy"), Typed((*Graph).KyvernoV1, (*KyvernoV1Graph).Policy))
+
+	Depends(v1.SchemeGroupVersion.WithKind("ClusterPolicy"), corev1.SchemeGroupVersion.WithKind("Namespace"))
+	Depends(v1.SchemeGroupVersion.WithKind("Policy"), corev1.SchemeGroupVersion.WithKind("Namespace"))
+}
+
+// KyvernoV1Graph is used to graph all Kyverno resources.
+type KyvernoV1Graph struct {
+	graph *Graph
+}
+
+// NewKyvernoV1Graph creates a new KyvernoV1Graph.
+func NewKyvernoV1Graph(g *Graph) *KyvernoV1Graph {
+	return &KyvernoV1Graph{
+		graph: g,
+	}
+}
+
+// KyvernoV1 retrieves the KyvernoV1Graph.
+func (g *Graph) KyvernoV1() *KyvernoV1Graph {
+	return g.kyvernoV1
+}
+
+// ClusterPolicy adds a v1.ClusterPolicy resource to the Graph.
+func (g *KyvernoV1Graph) ClusterPolicy(obj *v1.ClusterPolicy) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	g.graph.Defer(n, func() error {
+		return g.Spec(n, metav1.NamespaceAll, KyvernoAutogenControllers(obj), obj.Spec)
+	})
+
+	return n, nil
+}
+
+// Policy adds a v1.Policy resource to the Graph.
+func (g *KyvernoV1Graph) Policy(obj *v1.Policy) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	g.graph.Defer(n, func() error {
+		return g.Spec(n, obj.GetNamespace(), KyvernoAutogenControllers(obj), obj.Spec)
+	})
+
+	return n, nil
+}
+
+// Spec evaluates the rules of a policy against the nodes of the Graph and adds a Governs relationship
+// to every matched node. An empty namespace evaluates the rules against nodes in all namespaces.
+// It is deferred until all objects are resolved, so the rules match the related objects of the Graph as well.
+func (g *KyvernoV1Graph) Spec(n *Node, namespace string, controllers []string, spec v1.Spec) error {
+	rules := map[types.UID][]string{}
+	matched := []*Node{}
+	nodes := g.graph.NodeList()
+
+	for _, rule := range spec.Rules {
+		for _, obj := range nodes {
+			if obj.GetUID() == n.GetUID() || obj.Synthetic() || obj.Properties[placeholderProperty] != "" {
+				continue
+			}
+			if namespace != metav1.NamespaceAll && obj.GetNamespace() != namespace {
+				continue
+			}
+
+			match, err := g.MatchResources(rule.Match, obj, controllers)
+			if err != nil {
+				return err
+			}
+			if !match {
+				continue
+			}
+
+			if rule.Exclude != nil {
+				exclude, err := g.MatchResources(*rule.Exclude, obj, controllers)
+				if err != nil {
+					return err
+				}
+				if exclude {
+					continue
+				}
+			}
+
+			if _, ok := rules[obj.GetUID()]; !ok {
+				matched = append(matched, obj)
+			}
+			rules[obj.GetUID()] = append(rules[obj.GetUID()], rule.Name)
+		}
+	}
+
+	for _, obj := range matched {
+		r := g.graph.Relationship(n, "Governs", obj)
+		r.Attribute("rules", strings.Join(rules[obj.GetUID()], ", "))
+		if spec.ValidationFailureAction != "" {
+			r.Attribute("action", spec.ValidationFailureAction)
+		}
+	}
+
+	return nil
+}
+
+// MatchResources reports whether an object is selected by any or all resource filters, or by the resource description.
+func (g *KyvernoV1Graph) MatchResources(m v1.MatchResources, obj *Node, controllers []string) (bool, error) {
+	switch {
+	case len(m.Any) != 0:
+		for _, filter := range m.Any {
+			match, err := g.ResourceDescription(filter.Resources, obj, controllers)
+			if err != nil || match {
+				return match, err
+			}
+		}
+		return false, nil
+	case len(m.All) != 0:
+		for _, filter := range m.All {
+			match, err := g.ResourceDescription(filter.Resources, obj, controllers)
+			if err != nil || !match {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	d := m.Resources
+	if len(d.Kinds) == 0 && d.Name == "" && len(d.Names) == 0 && len(d.Namespaces) == 0 && d.Selector == nil && d.NamespaceSelector == nil {
+		return false, nil
+	}
+
+	return g.ResourceDescription(d, obj, controllers)
+}
+
+// ResourceDescription reports whether an object matches all conditions of a v1.ResourceDescription.
+func (g *KyvernoV1Graph) ResourceDescription(d v1.ResourceDescription, obj *Node, controllers []string) (bool, error) {
+	if len(d.Kinds) != 0 && !MatchAny(d.Kinds, func(kind string) bool { return KyvernoKindMatches(kind, obj, controllers) }) {
+		return false, nil
+	}
+
+	names := d.Names
+	if d.Name != "" {
+		names = append(names, d.Name)
+	}
+	if len(names) != 0 && !MatchAny(names, func(name string) bool { return MatchWildcard(name, obj.GetName()) }) {
+		return false, nil
+	}
+
+	namespace := obj.GetNamespace()
+	if obj.Kind == "Namespace" {
+		namespace = obj.GetName()
+	}
+
+	if len(d.Namespaces) != 0 && !MatchAny(d.Namespaces, func(ns string) bool { return MatchWildcard(ns, namespace) }) {
+		return false, nil
+	}
+
+	if d.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(d.Selector)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			return false, nil
+		}
+	}
+
+	if d.NamespaceSelector != nil {
+		if namespace == "" {
+			return false, nil
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(d.NamespaceSelector)
+		if err != nil {
+			return false, err
+		}
+
+		namespaceLabels, err := g.graph.NetworkingV1().NamespaceLabels(namespace)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(namespaceLabels) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// KyvernoKindMatches reports whether an object matches a Kyverno kind like "Pod", "v1/Pod" or "apps/v1/Deployment".
+// Rules for pods also match the given pod controllers.
+func KyvernoKindMatches(pattern string, obj *Node, controllers []string) bool {
+	gvk := obj.GroupVersionKind()
+
+	parts := strings.Split(pattern, "/")
+	kind := parts[len(parts)-1]
+	if len(parts) == 3 && (!MatchWildcard(parts[0], gvk.Group) || !MatchWildcard(parts[1], gvk.Version)) {
+		return false
+	}
+	// Nodes of core kinds are added without a version, so their version can not be compared.
+	if len(parts) == 2 && gvk.Version != "" && !MatchWildcard(parts[0], gvk.Version) {
+		return false
+	}
+
+	if kind == "Pod" && MatchAny(controllers, func(controller string) bool { return controller == gvk.Kind }) {
+		return true
+	}
+
+	return MatchWildcard(kind, gvk.Kind)
+}
+
+// KyvernoAutogenControllers returns the pod controllers that Pod rules of a policy are generated for.
+func KyvernoAutogenControllers(obj metav1.Object) []string {
+	value, ok := obj.GetAnnotations()[kyvernoAutogenAnnotation]
+	switch {
+	case !ok:
+		return kyvernoAutogenControllers
+	case value == "none" || value == "":
+		return []string{}
+	}
+
+	return strings.Split(value, ",")
+}
+
+// MatchWildcard reports whether a name matches a pattern with "*" and "?" wildcards.
+func MatchWildcard(pattern string, name string) bool {
+	match, err := path.Match(pattern, name)
+	return err == nil && match
+}
+
+// MatchAny reports whether any of the values matches.
+func MatchAny(values []string, match func(string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+
+	return false
+}
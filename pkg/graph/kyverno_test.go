@@ -0,0 +1,196 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/steveteuber/kubectl-graph/pkg/apis/kyverno/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// matchManifests are the objects which the policy matchers are evaluated against.
+const matchManifests = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+  uid: ns-shop
+  labels:
+    env: prod
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: dev
+  uid: ns-dev
+  labels:
+    env: dev
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-1
+  namespace: shop
+  uid: pod-web-1
+  labels:
+    app: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+  uid: deployment-web
+  labels:
+    app: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: dev
+  uid: configmap-settings
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-1
+  uid: node-worker-1
+`
+
+// testMatchGraph creates a Graph of the match manifests and returns its nodes by "Kind/name".
+func testMatchGraph(t *testing.T) (*Graph, map[string]*Node) {
+	t.Helper()
+
+	g, err := NewGraph(context.Background(), nil, testObjects(t, matchManifests), &Options{Concurrency: 1}, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := map[string]*Node{}
+	for _, n := range g.NodeList() {
+		nodes[n.Kind+"/"+n.Name] = n
+	}
+
+	return g, nodes
+}
+
+func TestKyvernoMatchResources(t *testing.T) {
+	g, nodes := testMatchGraph(t)
+
+	resources := func(d v1.ResourceDescription) v1.MatchResources {
+		return v1.MatchResources{Resources: d}
+	}
+
+	tests := []struct {
+		name        string
+		match       v1.MatchResources
+		controllers []string
+		object      string
+		want        bool
+	}{
+		{"empty", v1.MatchResources{}, nil, "Pod/web-1", false},
+		{"kind", resources(v1.ResourceDescription{Kinds: []string{"Pod"}}), nil, "Pod/web-1", true},
+		{"other kind", resources(v1.ResourceDescription{Kinds: []string{"Pod"}}), nil, "ConfigMap/settings", false},
+		{"version and kind", resources(v1.ResourceDescription{Kinds: []string{"v1/Pod"}}), nil, "Pod/web-1", true},
+		{"other version", resources(v1.ResourceDescription{Kinds: []string{"v1beta1/Deployment"}}), nil, "Deployment/web", false},
+		{"version", resources(v1.ResourceDescription{Kinds: []string{"v1/Deployment"}}), nil, "Deployment/web", true},
+		{"group, version and kind", resources(v1.ResourceDescription{Kinds: []string{"apps/v1/Deployment"}}), nil, "Deployment/web", true},
+		{"wildcard version", resources(v1.ResourceDescription{Kinds: []string{"apps/*/Deployment"}}), nil, "Deployment/web", true},
+		{"other group", resources(v1.ResourceDescription{Kinds: []string{"batch/v1/Deployment"}}), nil, "Deployment/web", false},
+		{"wildcard kind", resources(v1.ResourceDescription{Kinds: []string{"Config*"}}), nil, "ConfigMap/settings", true},
+		{"autogen controller", resources(v1.ResourceDescription{Kinds: []string{"Pod"}}), kyvernoAutogenControllers, "Deployment/web", true},
+		{"autogen disabled", resources(v1.ResourceDescription{Kinds: []string{"Pod"}}), []string{}, "Deployment/web", false},
+		{"wildcard name", resources(v1.ResourceDescription{Name: "web-*"}), nil, "Pod/web-1", true},
+		{"other name", resources(v1.ResourceDescription{Names: []string{"web-*", "api"}}), nil, "Deployment/web", false},
+		{"wildcard namespace", resources(v1.ResourceDescription{Namespaces: []string{"sh*"}}), nil, "Pod/web-1", true},
+		{"other namespace", resources(v1.ResourceDescription{Namespaces: []string{"sh*"}}), nil, "ConfigMap/settings", false},
+		{"namespace itself", resources(v1.ResourceDescription{Namespaces: []string{"dev"}}), nil, "Namespace/dev", true},
+		{"selector", resources(v1.ResourceDescription{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}}), nil, "Pod/web-1", true},
+		{"other selector", resources(v1.ResourceDescription{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}}), nil, "Pod/web-1", false},
+		{"namespace selector", resources(v1.ResourceDescription{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}}), nil, "Pod/web-1", true},
+		{"other namespace selector", resources(v1.ResourceDescription{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}}), nil, "ConfigMap/settings", false},
+		{"namespace selector of cluster object", resources(v1.ResourceDescription{NamespaceSelector: &metav1.LabelSelector{}}), nil, "Node/worker-1", false},
+		{
+			"any",
+			v1.MatchResources{Any: []v1.ResourceFilter{
+				{Resources: v1.ResourceDescription{Kinds: []string{"Deployment"}}},
+				{Resources: v1.ResourceDescription{Kinds: []string{"ConfigMap"}}},
+			}},
+			nil, "ConfigMap/settings", true,
+		},
+		{
+			"any without match",
+			v1.MatchResources{Any: []v1.ResourceFilter{
+				{Resources: v1.ResourceDescription{Kinds: []string{"Deployment"}}},
+				{Resources: v1.ResourceDescription{Namespaces: []string{"shop"}}},
+			}},
+			nil, "ConfigMap/settings", false,
+		},
+		{
+			"all",
+			v1.MatchResources{All: []v1.ResourceFilter{
+				{Resources: v1.ResourceDescription{Kinds: []string{"Pod"}}},
+				{Resources: v1.ResourceDescription{Namespaces: []string{"shop"}}},
+			}},
+			nil, "Pod/web-1", true,
+		},
+		{
+			"all without match",
+			v1.MatchResources{All: []v1.ResourceFilter{
+				{Resources: v1.ResourceDescription{Kinds: []string{"Pod"}}},
+				{Resources: v1.ResourceDescription{Namespaces: []string{"dev"}}},
+			}},
+			nil, "Pod/web-1", false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, ok := nodes[tt.object]
+			if !ok {
+				t.Fatalf("node %s not found", tt.object)
+			}
+
+			got, err := g.KyvernoV1().MatchResources(tt.match, obj, tt.controllers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("MatchResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKyvernoAutogenControllers(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		want        int
+	}{
+		{nil, len(kyvernoAutogenControllers)},
+		{map[string]string{kyvernoAutogenAnnotation: "none"}, 0},
+		{map[string]string{kyvernoAutogenAnnotation: "Deployment,StatefulSet"}, 2},
+	}
+
+	for _, tt := range tests {
+		obj := &metav1.ObjectMeta{Annotations: tt.annotations}
+		if got := KyvernoAutogenControllers(obj); len(got) != tt.want {
+			t.Errorf("KyvernoAutogenControllers(%v) = %q, want %d controllers", tt.annotations, got, tt.want)
+		}
+	}
+}
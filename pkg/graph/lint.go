@@ -0,0 +1,557 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Lint rules which are reported by Lint.
+const (
+	RuleMissingReference   = "missing-reference"
+	RuleUnmatchedSelector  = "unmatched-selector"
+	RuleMissingBackend     = "missing-backend"
+	RuleUnreferencedObject = "unreferenced-object"
+	RuleUnclaimedVolume    = "unclaimed-volume"
+)
+
+// Severities of lint findings.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// lintRules describes all lint rules.
+var lintRules = map[string]string{
+	RuleMissingReference:   "Pods and pod templates must not reference missing config maps, secrets or persistent volume claims.",
+	RuleUnmatchedSelector:  "The selector of a service should match at least one pod.",
+	RuleMissingBackend:     "Ingress backends must not reference missing services.",
+	RuleUnreferencedObject: "Config maps and secrets should be referenced by at least one object.",
+	RuleUnclaimedVolume:    "Persistent volumes should be claimed by an existing persistent volume claim.",
+}
+
+// unreferencedExceptions are objects which are managed by Kubernetes or tools and are never referenced by other objects.
+var unreferencedExceptions = map[string]func(obj *unstructured.Unstructured) bool{
+	"ConfigMap": func(obj *unstructured.Unstructured) bool {
+		return obj.GetName() == "kube-root-ca.crt"
+	},
+	"Secret": func(obj *unstructured.Unstructured) bool {
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return secretType == string(corev1.SecretTypeServiceAccountToken) ||
+			secretType == string(corev1.SecretTypeBootstrapToken) ||
+			secretType == "helm.sh/release.v1"
+	},
+}
+
+// Finding is a problem of an object which was found by Lint.
+type Finding struct {
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Message   string `json:"message"`
+}
+
+// LintReport stores all findings of Lint.
+type LintReport struct {
+	Findings []Finding
+}
+
+// lintTarget is a pod or a workload with a pod template.
+type lintTarget struct {
+	obj    *unstructured.Unstructured
+	labels labels.Set
+	spec   *corev1.PodSpec
+}
+
+// linter stores the state of a single Lint run.
+type linter struct {
+	graph   *Graph
+	report  *LintReport
+	exists  map[string]bool
+	targets []lintTarget
+	objects []lintTarget
+}
+
+// Lint reports dangling references and orphaned objects of the Graph. References of pods and workloads
+// are checked against the objects of the Graph first and against the ObjectSource of the Graph second.
+func (g *Graph) Lint() (*LintReport, error) {
+	l := &linter{
+		graph:  g,
+		report: &LintReport{Findings: []Finding{}},
+		exists: make(map[string]bool),
+	}
+
+	if err := l.collectTargets(); err != nil {
+		return nil, err
+	}
+
+	checks := []func() error{
+		l.missingReferences,
+		l.unmatchedSelectors,
+		l.missingBackends,
+		l.unreferencedObjects,
+		l.unclaimedVolumes,
+	}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(l.report.Findings, func(i, j int) bool {
+		a, b := l.report.Findings[i], l.report.Findings[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Message < b.Message
+	})
+
+	return l.report, nil
+}
+
+// collectTargets collects all pods and workloads of the Graph. Without local manifests, the pods of all
+// namespaces in the Graph are listed as well, because they may reference objects of the Graph.
+func (l *linter) collectTargets() error {
+	uids := make(map[types.UID]bool)
+	namespaces := make(map[string]bool)
+
+	for _, obj := range l.graph.objects {
+		if obj.GetNamespace() != "" {
+			namespaces[obj.GetNamespace()] = true
+		}
+
+		spec, ok := PodTemplateSpec(obj)
+		if !ok {
+			continue
+		}
+		podLabels, _ := PodTemplateLabels(obj)
+
+		target := lintTarget{obj: obj, labels: labels.Set(podLabels), spec: spec}
+		l.objects = append(l.objects, target)
+		l.targets = append(l.targets, target)
+		uids[obj.GetUID()] = true
+	}
+
+	if l.graph.local {
+		return nil
+	}
+
+	names := []string{}
+	for namespace := range namespaces {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	gvk := corev1.SchemeGroupVersion.WithKind("Pod")
+	for _, namespace := range names {
+		pods, err := l.graph.list(gvk, namespace, labels.Everything())
+		if err != nil {
+			if _, err := l.graph.Degrade(gvk, namespace, "", err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, pod := range pods {
+			if uids[pod.GetUID()] {
+				continue
+			}
+
+			spec, ok := PodTemplateSpec(pod)
+			if !ok {
+				continue
+			}
+			l.targets = append(l.targets, lintTarget{obj: pod, labels: labels.Set(pod.GetLabels()), spec: spec})
+		}
+	}
+
+	return nil
+}
+
+// missingReferences reports pods and workloads which reference missing config maps, secrets or persistent volume claims.
+func (l *linter) missingReferences() error {
+	for _, target := range l.objects {
+		reported := make(map[string]bool)
+
+		for _, ref := range PodSpecReferences(target.spec) {
+			if ref.Optional || ref.Name == "" || reported[ref.Kind+"/"+ref.Name] {
+				continue
+			}
+
+			ok, err := l.exist(corev1.SchemeGroupVersion.WithKind(ref.Kind), target.obj.GetNamespace(), ref.Name)
+			if err != nil {
+				return err
+			}
+			if ok {
+				continue
+			}
+
+			reported[ref.Kind+"/"+ref.Name] = true
+			l.add(RuleMissingReference, SeverityError, target.obj, fmt.Sprintf("references missing %s %q", ref.Kind, ref.Name))
+		}
+	}
+
+	return nil
+}
+
+// unmatchedSelectors reports services whose selector matches no pod or pod template.
+func (l *linter) unmatchedSelectors() error {
+	for _, obj := range l.objectsOf(corev1.SchemeGroupVersion.WithKind("Service").GroupKind()) {
+		service := &corev1.Service{}
+		if err := FromUnstructured(obj, service); err != nil {
+			return err
+		}
+		if len(service.Spec.Selector) == 0 || service.Spec.Type == corev1.ServiceTypeExternalName {
+			continue
+		}
+
+		selector := labels.SelectorFromSet(service.Spec.Selector)
+		matched := false
+		for _, target := range l.targets {
+			if target.obj.GetNamespace() == service.GetNamespace() && selector.Matches(target.labels) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			l.add(RuleUnmatchedSelector, SeverityWarning, obj, fmt.Sprintf("selector %q matches no pods", selector.String()))
+		}
+	}
+
+	return nil
+}
+
+// missingBackends reports ingresses whose backends were resolved to missing services.
+func (l *linter) missingBackends() error {
+	for _, node := range l.graph.NodesByKind(corev1.SchemeGroupVersion.WithKind("Service").GroupKind(), metav1.NamespaceAll) {
+		if node.Properties[placeholderProperty] != ReasonMissing {
+			continue
+		}
+
+		for _, r := range l.graph.Relationships[node.UID] {
+			from, ok := l.graph.Nodes[r.From]
+			if !ok || from.Kind != "Ingress" {
+				continue
+			}
+
+			l.report.Findings = append(l.report.Findings, Finding{
+				Rule:      RuleMissingBackend,
+				Severity:  SeverityError,
+				Kind:      from.Kind,
+				Namespace: from.Namespace,
+				Name:      from.Name,
+				Message:   fmt.Sprintf("backend references missing Service %q", node.Name),
+			})
+		}
+	}
+
+	return nil
+}
+
+// unreferencedObjects reports config maps and secrets which are not referenced by any pod, workload or other resolved object.
+func (l *linter) unreferencedObjects() error {
+	referenced := make(map[string]bool)
+	key := func(kind string, namespace string, name string) string {
+		return kind + "/" + namespace + "/" + name
+	}
+
+	for _, target := range l.targets {
+		for _, ref := range PodSpecReferences(target.spec) {
+			referenced[key(ref.Kind, target.obj.GetNamespace(), ref.Name)] = true
+		}
+	}
+
+	for _, obj := range l.graph.objects {
+		if obj.GetKind() != "Ingress" {
+			continue
+		}
+		tls, _, _ := unstructured.NestedSlice(obj.Object, "spec", "tls")
+		for _, entry := range tls {
+			if secretName, _, _ := unstructured.NestedString(entry.(map[string]interface{}), "secretName"); secretName != "" {
+				referenced[key("Secret", obj.GetNamespace(), secretName)] = true
+			}
+		}
+	}
+
+	for _, kind := range []string{"ConfigMap", "Secret"} {
+		for _, obj := range l.objectsOf(corev1.SchemeGroupVersion.WithKind(kind).GroupKind()) {
+			if referenced[key(kind, obj.GetNamespace(), obj.GetName())] || unreferencedExceptions[kind](obj) {
+				continue
+			}
+
+			used := false
+			for _, r := range l.graph.Relationships[obj.GetUID()] {
+				if from, ok := l.graph.Nodes[r.From]; ok && from.Kind != "Namespace" && from.Kind != "Cluster" {
+					used = true
+					break
+				}
+			}
+
+			if !used {
+				l.add(RuleUnreferencedObject, SeverityWarning, obj, "is not referenced by any object")
+			}
+		}
+	}
+
+	return nil
+}
+
+// unclaimedVolumes reports persistent volumes without a claim, or with a claim which does not exist.
+func (l *linter) unclaimedVolumes() error {
+	for _, obj := range l.objectsOf(corev1.SchemeGroupVersion.WithKind("PersistentVolume").GroupKind()) {
+		pv := &corev1.PersistentVolume{}
+		if err := FromUnstructured(obj, pv); err != nil {
+			return err
+		}
+
+		if pv.Spec.ClaimRef == nil {
+			l.add(RuleUnclaimedVolume, SeverityWarning, obj, "is not claimed by any PersistentVolumeClaim")
+			continue
+		}
+
+		ok, err := l.exist(corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim"), pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			l.add(RuleUnclaimedVolume, SeverityWarning, obj, fmt.Sprintf("is claimed by missing PersistentVolumeClaim %q", pv.Spec.ClaimRef.Namespace+"/"+pv.Spec.ClaimRef.Name))
+		}
+	}
+
+	return nil
+}
+
+// exist checks whether an object is part of the Graph or can be retrieved from its ObjectSource.
+// Objects which can not be retrieved for other reasons are assumed to exist.
+func (l *linter) exist(gvk schema.GroupVersionKind, namespace string, name string) (bool, error) {
+	key := gvk.Kind + "/" + namespace + "/" + name
+	if ok, found := l.exists[key]; found {
+		return ok, nil
+	}
+
+	for _, obj := range l.graph.objects {
+		if obj.GroupVersionKind().GroupKind() == gvk.GroupKind() && obj.GetNamespace() == namespace && obj.GetName() == name {
+			l.exists[key] = true
+			return true, nil
+		}
+	}
+
+	_, err := l.graph.get(gvk, namespace, name)
+	switch {
+	case err == nil:
+		l.exists[key] = true
+	case apierrors.IsNotFound(err):
+		l.exists[key] = false
+	default:
+		if _, err := l.graph.Degrade(gvk, namespace, name, err); err != nil {
+			return false, err
+		}
+		l.exists[key] = true
+	}
+
+	return l.exists[key], nil
+}
+
+// objectsOf returns all objects of the Graph by group and kind.
+func (l *linter) objectsOf(gk schema.GroupKind) []*unstructured.Unstructured {
+	return l.graph.Objects(gk, metav1.NamespaceAll, labels.Everything())
+}
+
+// add adds a finding for an object to the report.
+func (l *linter) add(rule string, severity string, obj *unstructured.Unstructured, message string) {
+	l.report.Findings = append(l.report.Findings, Finding{
+		Rule:      rule,
+		Severity:  severity,
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Message:   message,
+	})
+}
+
+// Errors returns the number of findings with error severity.
+func (r *LintReport) Errors() int {
+	errors := 0
+	for _, finding := range r.Findings {
+		if finding.Severity == SeverityError {
+			errors++
+		}
+	}
+
+	return errors
+}
+
+// Write writes the report in text, json or sarif format.
+func (r *LintReport) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		return r.WriteJSON(w)
+	case "sarif":
+		return r.WriteSARIF(w)
+	}
+
+	return r.WriteText(w)
+}
+
+// WriteText writes the report as table.
+func (r *LintReport) WriteText(w io.Writer) error {
+	if len(r.Findings) == 0 {
+		_, err := fmt.Fprintln(w, "No problems found.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEVERITY\tRULE\tOBJECT\tMESSAGE")
+	for _, finding := range r.Findings {
+		fmt.Fprintln(tw, strings.Join([]string{finding.Severity, finding.Rule, finding.Object(), finding.Message}, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// WriteJSON writes the findings as JSON array.
+func (r *LintReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r.Findings)
+}
+
+// Object returns the kind, namespace and name of the object of a finding.
+func (f Finding) Object() string {
+	if f.Namespace == "" {
+		return f.Kind + "/" + f.Name
+	}
+
+	return f.Kind + "/" + f.Namespace + "/" + f.Name
+}
+
+// sarifLog is the root object of a SARIF 2.1.0 log file.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// WriteSARIF writes the report as SARIF 2.1.0 log, the objects of the findings are logical locations.
+func (r *LintReport) WriteSARIF(w io.Writer) error {
+	rules := []sarifRule{}
+	for id, description := range lintRules {
+		rules = append(rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: description}})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	results := []sarifResult{}
+	for _, finding := range r.Findings {
+		results = append(results, sarifResult{
+			RuleID:  finding.Rule,
+			Level:   finding.Severity,
+			Message: sarifMessage{Text: finding.Object() + " " + finding.Message},
+			Locations: []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{
+					Name:               finding.Name,
+					FullyQualifiedName: finding.Object(),
+					Kind:               "resource",
+				}},
+			}},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "kubectl-graph",
+				InformationURI: "https://github.com/steveteuber/kubectl-graph",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(log)
+}
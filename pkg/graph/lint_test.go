@@ -0,0 +1,62 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "lint.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := testObjects(t, string(b))
+
+	g, err := NewGraph(context.Background(), nil, objs, &Options{Concurrency: 1}, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := g.Lint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := map[string]bool{}
+	for _, finding := range report.Findings {
+		rules[finding.Rule] = true
+	}
+	for rule := range lintRules {
+		if !rules[rule] {
+			t.Errorf("rule %s is not reported", rule)
+		}
+	}
+
+	for _, format := range []string{"text", "json", "sarif"} {
+		t.Run(format, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			if err := report.Write(b, format); err != nil {
+				t.Fatal(err)
+			}
+
+			testGolden(t, "lint-"+format+".golden", b.Bytes())
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"strings"
+
+	v1 "github.com/steveteuber/kubectl-graph/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func init() {
+	Register(v1.SchemeGroupVersion.WithKind("Prometheus"), Typed((*Graph).MonitoringV1, (*MonitoringV1Graph).Prometheus))
+	Register(v1.SchemeGroupVersion.WithKind("ServiceMonitor"), Typed((*Graph).MonitoringV1, (*MonitoringV1Graph).ServiceMonitor))
+	Register(v1.SchemeGroupVersion.WithKind("PodMonitor"), Typed((*Graph).MonitoringV1, (*MonitoringV1Graph).PodMonitor))
+
+	Depends(v1.SchemeGroupVersion.WithKind("Prometheus"), corev1.SchemeGroupVersion.WithKind("Namespace"))
+	Depends(v1.SchemeGroupVersion.WithKind("ServiceMonitor"), corev1.SchemeGroupVersion.WithKind("Service"))
+	Depends(v1.SchemeGroupVersion.WithKind("PodMonitor"), corev1.SchemeGroupVersion.WithKind("Pod"))
+}
+
+// MonitoringV1Graph is used to graph all Prometheus Operator resources.
+type MonitoringV1Graph struct {
+	graph *Graph
+}
+
+// NewMonitoringV1Graph creates a new MonitoringV1Graph.
+func NewMonitoringV1Graph(g *Graph) *MonitoringV1Graph {
+	return &MonitoringV1Graph{
+		graph: g,
+	}
+}
+
+// MonitoringV1 retrieves the MonitoringV1Graph.
+func (g *Graph) MonitoringV1() *MonitoringV1Graph {
+	return g.monitoringV1
+}
+
+// Prometheus adds a v1.Prometheus resource to the Graph.
+//
+// Monitors and rules are only linked when they are part of the objects of the Graph.
+func (g *MonitoringV1Graph) Prometheus(obj *v1.Prometheus) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if obj.Spec.ServiceAccountName != "" {
+		sa, err := g.graph.CoreV1().ServiceAccount(obj.GetNamespace(), obj.Spec.ServiceAccountName)
+		if err != nil {
+			return nil, err
+		}
+		g.graph.Relationship(n, "ServiceAccount", sa)
+	}
+
+	selectors := []struct {
+		kind              string
+		selector          *metav1.LabelSelector
+		namespaceSelector *metav1.LabelSelector
+	}{
+		{"ServiceMonitor", obj.Spec.ServiceMonitorSelector, obj.Spec.ServiceMonitorNamespaceSelector},
+		{"PodMonitor", obj.Spec.PodMonitorSelector, obj.Spec.PodMonitorNamespaceSelector},
+		{"PrometheusRule", obj.Spec.RuleSelector, obj.Spec.RuleNamespaceSelector},
+	}
+
+	for _, s := range selectors {
+		if s.selector == nil {
+			continue
+		}
+
+		objs, err := g.Select(v1.SchemeGroupVersion.WithKind(s.kind).GroupKind(), obj.GetNamespace(), s.selector, s.namespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range objs {
+			g.graph.Relationship(n, s.kind, g.graph.Node(o.GroupVersionKind(), o))
+		}
+	}
+
+	return n, nil
+}
+
+// Select returns all objects of the Graph matching the selectors of a Prometheus.
+// A nil namespace selector only selects objects in the namespace of the Prometheus.
+func (g *MonitoringV1Graph) Select(gk schema.GroupKind, namespace string, selector *metav1.LabelSelector, namespaceSelector *metav1.LabelSelector) ([]*unstructured.Unstructured, error) {
+	objSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespaceSelector == nil {
+		return g.graph.Objects(gk, namespace, objSelector), nil
+	}
+
+	nsSelector, err := metav1.LabelSelectorAsSelector(namespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	objs := []*unstructured.Unstructured{}
+	for _, obj := range g.graph.Objects(gk, metav1.NamespaceAll, objSelector) {
+		namespaceLabels, err := g.graph.NetworkingV1().NamespaceLabels(obj.GetNamespace())
+		if err != nil {
+			return nil, err
+		}
+		if nsSelector.Matches(namespaceLabels) {
+			objs = append(objs, obj)
+		}
+	}
+
+	return objs, nil
+}
+
+// ServiceMonitor adds a v1.ServiceMonitor resource to the Graph.
+func (g *MonitoringV1Graph) ServiceMonitor(obj *v1.ServiceMonitor) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	selector, err := metav1.LabelSelectorAsSelector(&obj.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := []string{}
+	for _, endpoint := range obj.Spec.Endpoints {
+		ports = append(ports, MonitoringPort(endpoint.Port, endpoint.TargetPort, endpoint.Path))
+	}
+
+	for _, namespace := range MonitoringNamespaces(obj.GetNamespace(), obj.Spec.NamespaceSelector) {
+		services, err := g.graph.CoreV1().SelectServices(namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range services {
+			g.Relationship(n, s, ports)
+		}
+	}
+
+	return n, nil
+}
+
+// PodMonitor adds a v1.PodMonitor resource to the Graph.
+func (g *MonitoringV1Graph) PodMonitor(obj *v1.PodMonitor) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	selector, err := metav1.LabelSelectorAsSelector(&obj.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := []string{}
+	for _, endpoint := range obj.Spec.PodMetricsEndpoints {
+		ports = append(ports, MonitoringPort(endpoint.Port, endpoint.TargetPort, endpoint.Path))
+	}
+
+	for _, namespace := range MonitoringNamespaces(obj.GetNamespace(), obj.Spec.NamespaceSelector) {
+		pods, err := g.graph.NetworkingV1().SelectPods(namespace, nil, selector)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range pods {
+			g.Relationship(n, p, ports)
+		}
+	}
+
+	return n, nil
+}
+
+// Relationship creates a new scrape relationship from a monitor to its target with the scraped endpoints.
+func (g *MonitoringV1Graph) Relationship(from *Node, to *Node, ports []string) *Relationship {
+	r := g.graph.Relationship(from, "Scrapes", to)
+	if len(ports) != 0 {
+		r.Attribute("ports", strings.Join(ports, ", "))
+	}
+
+	return r
+}
+
+// MonitoringNamespaces returns the namespaces which are selected by a v1.NamespaceSelector of a monitor.
+// An empty namespace selects all namespaces.
+func MonitoringNamespaces(namespace string, selector v1.NamespaceSelector) []string {
+	switch {
+	case selector.Any:
+		return []string{metav1.NamespaceAll}
+	case len(selector.MatchNames) != 0:
+		return selector.MatchNames
+	}
+
+	return []string{namespace}
+}
+
+// MonitoringPort formats a scrape endpoint as port:path.
+func MonitoringPort(port string, targetPort *intstr.IntOrString, path string) string {
+	if port == "" && targetPort != nil {
+		port = targetPort.String()
+	}
+	if path == "" {
+		path = "/metrics"
+	}
+
+	return port + ":" + path
+}
@@ -15,24 +15,48 @@
 package graph
 
 import (
-	"context"
 	"fmt"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
+	"k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+func init() {
+	Register(v1.SchemeGroupVersion.WithKind("Ingress"), Typed((*Graph).NetworkingV1, (*NetworkingV1Graph).Ingress))
+	Register(v1.SchemeGroupVersion.WithKind("NetworkPolicy"), Typed((*Graph).NetworkingV1, (*NetworkingV1Graph).NetworkPolicy))
+
+	// Ingresses of the deprecated versions are converted and resolved like networking.k8s.io/v1 ingresses.
+	ingressV1beta1 := Typed((*Graph).NetworkingV1, (*NetworkingV1Graph).IngressV1beta1)
+	Register(v1beta1.SchemeGroupVersion.WithKind("Ingress"), ingressV1beta1)
+	Register(schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}, ingressV1beta1)
+
+	Depends(v1.SchemeGroupVersion.WithKind("Ingress"), corev1.SchemeGroupVersion.WithKind("Service"))
+	Depends(v1beta1.SchemeGroupVersion.WithKind("Ingress"), corev1.SchemeGroupVersion.WithKind("Service"))
+	Depends(schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}, corev1.SchemeGroupVersion.WithKind("Service"))
+	Depends(v1.SchemeGroupVersion.WithKind("NetworkPolicy"), corev1.SchemeGroupVersion.WithKind("Pod"), corev1.SchemeGroupVersion.WithKind("Namespace"))
+}
+
 // NetworkingV1Graph is used to graph all networking resources.
 type NetworkingV1Graph struct {
 	graph *Graph
+
+	policies   map[types.UID]*v1.NetworkPolicy
+	namespaces map[string]labels.Set
 }
 
 // NewNetworkingV1Graph creates a new NetworkingV1Graph.
 func NewNetworkingV1Graph(g *Graph) *NetworkingV1Graph {
 	return &NetworkingV1Graph{
-		graph: g,
+		graph:      g,
+		policies:   make(map[types.UID]*v1.NetworkPolicy),
+		namespaces: make(map[string]labels.Set),
 	}
 }
 
@@ -41,40 +65,70 @@ func (g *Graph) NetworkingV1() *NetworkingV1Graph {
 	return g.networkingV1
 }
 
-// Unstructured adds an unstructured node to the Graph.
-func (g *NetworkingV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
-	switch unstr.GetKind() {
-	case "Ingress":
-		obj := &v1.Ingress{}
-		if err := FromUnstructured(unstr, obj); err != nil {
-			return nil, err
-		}
-		return g.Ingress(obj)
-	case "NetworkPolicy":
-		obj := &v1.NetworkPolicy{}
-		if err := FromUnstructured(unstr, obj); err != nil {
-			return nil, err
-		}
-		return g.NetworkPolicy(obj)
-	default:
-		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+// Relationship creates a new relationship between two nodes based on v1.PolicyType.
+func (g *NetworkingV1Graph) Relationship(from *Node, policyType v1.PolicyType, to *Node) *Relationship {
+	return g.relationship(from, policyType, to, nil)
+}
+
+// Rule creates a new relationship between a policy and a peer of one of its rules. The action and the except
+// CIDRs of the rule are part of the identity of the relationship, so rules with different actions or exceptions
+// are kept as separate relationships, while the ports of rules with the same action and exceptions are merged.
+func (g *NetworkingV1Graph) Rule(from *Node, policyType v1.PolicyType, to *Node, action string, except []string, ports []string) *Relationship {
+	scope := map[string]string{"action": action}
+	if len(except) != 0 {
+		scope["except"] = strings.Join(MergeValues(except, nil), ", ")
 	}
+
+	return g.Ports(g.relationship(from, policyType, to, scope), ports)
 }
 
-// Relationship creates a new relationship between two nodes based on v1.PolicyType.
-func (g *NetworkingV1Graph) Relationship(from *Node, policyType v1.PolicyType, to *Node) (r *Relationship) {
+// relationship creates a new relationship between two nodes based on v1.PolicyType with the given scoped attributes.
+func (g *NetworkingV1Graph) relationship(from *Node, policyType v1.PolicyType, to *Node, scope map[string]string) (r *Relationship) {
 	switch policyType {
 	case v1.PolicyTypeIngress:
-		r = g.graph.Relationship(to, string(policyType), from)
+		r = g.graph.ScopedRelationship(to, string(policyType), from, scope)
 		r.Attribute("color", "#34a853")
 	case v1.PolicyTypeEgress:
-		r = g.graph.Relationship(from, string(policyType), to)
+		r = g.graph.ScopedRelationship(from, string(policyType), to, scope)
 		r.Attribute("color", "#ea4335")
 	}
 
 	return r.Attribute("style", "dashed")
 }
 
+// Ports adds a list of allowed ports to a relationship. An empty list allows all ports and adds the wildcard "*",
+// which absorbs all specific ports when rules for the same peer are merged.
+func (g *NetworkingV1Graph) Ports(r *Relationship, ports []string) *Relationship {
+	if len(ports) == 0 {
+		ports = []string{"*"}
+	}
+
+	return r.Attribute("ports", strings.Join(MergeValues(ports, nil), ", "))
+}
+
+// NetworkPolicyPorts formats a list of v1.NetworkPolicyPort as protocol/port strings.
+func NetworkPolicyPorts(ports []v1.NetworkPolicyPort) []string {
+	formatted := []string{}
+
+	for _, port := range ports {
+		protocol := corev1.ProtocolTCP
+		if port.Protocol != nil {
+			protocol = *port.Protocol
+		}
+
+		switch {
+		case port.Port == nil:
+			formatted = append(formatted, fmt.Sprintf("%s/*", protocol))
+		case port.EndPort != nil:
+			formatted = append(formatted, fmt.Sprintf("%s/%s-%d", protocol, port.Port.String(), *port.EndPort))
+		default:
+			formatted = append(formatted, fmt.Sprintf("%s/%s", protocol, port.Port.String()))
+		}
+	}
+
+	return formatted
+}
+
 // Ingress adds a v1.Ingress resource to the Graph.
 func (g *NetworkingV1Graph) Ingress(obj *v1.Ingress) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
@@ -100,17 +154,43 @@ func (g *NetworkingV1Graph) Ingress(obj *v1.Ingress) (*Node, error) {
 	return n, nil
 }
 
+// IngressV1beta1 adds a v1beta1.Ingress resource to the Graph by converting it into a v1.Ingress.
+func (g *NetworkingV1Graph) IngressV1beta1(obj *v1beta1.Ingress) (*Node, error) {
+	ingress := &v1.Ingress{TypeMeta: obj.TypeMeta, ObjectMeta: obj.ObjectMeta}
+
+	for _, rule := range obj.Spec.Rules {
+		r := v1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			r.HTTP = &v1.HTTPIngressRuleValue{}
+			for _, path := range rule.HTTP.Paths {
+				r.HTTP.Paths = append(r.HTTP.Paths, v1.HTTPIngressPath{Path: path.Path, Backend: IngressBackendV1beta1(path.Backend)})
+			}
+		}
+		ingress.Spec.Rules = append(ingress.Spec.Rules, r)
+	}
+
+	return g.Ingress(ingress)
+}
+
+// IngressBackendV1beta1 converts a v1beta1.IngressBackend into a v1.IngressBackend.
+func IngressBackendV1beta1(backend v1beta1.IngressBackend) v1.IngressBackend {
+	if backend.Resource != nil {
+		return v1.IngressBackend{Resource: backend.Resource}
+	}
+
+	port := v1.ServiceBackendPort{Name: backend.ServicePort.StrVal}
+	if backend.ServicePort.Type == intstr.Int {
+		port = v1.ServiceBackendPort{Number: backend.ServicePort.IntVal}
+	}
+
+	return v1.IngressBackend{Service: &v1.IngressServiceBackend{Name: backend.ServiceName, Port: port}}
+}
+
 // IngressBackend adds a v1.IngressBackend resource to the Graph.
 func (g *NetworkingV1Graph) IngressBackend(obj *v1.Ingress, backend v1.IngressBackend) (*Node, error) {
 	switch {
 	case backend.Service != nil:
-		options := metav1.GetOptions{}
-		service, err := g.graph.clientset.CoreV1().Services(obj.GetNamespace()).Get(context.TODO(), backend.Service.Name, options)
-		if err != nil {
-			return nil, err
-		}
-
-		return g.graph.CoreV1().Service(service)
+		return g.graph.CoreV1().ServiceRef(obj.GetNamespace(), backend.Service.Name)
 	case backend.Resource != nil:
 		return g.graph.CoreV1().TypedLocalObjectReference(backend.Resource, obj.GetNamespace())
 	}
@@ -120,10 +200,11 @@ func (g *NetworkingV1Graph) IngressBackend(obj *v1.Ingress, backend v1.IngressBa
 
 // Host adds a v1.Host resource to the Graph.
 func (g *NetworkingV1Graph) Host(name string) (*Node, error) {
+	gvk := schema.FromAPIVersionAndKind(v1.GroupName, "Host")
 	n := g.graph.Node(
-		schema.FromAPIVersionAndKind(v1.GroupName, "Host"),
+		gvk,
 		&metav1.ObjectMeta{
-			UID:  ToUID(name),
+			UID:  g.graph.SyntheticUID(gvk, "", name),
 			Name: name,
 		},
 	)
@@ -134,23 +215,19 @@ func (g *NetworkingV1Graph) Host(name string) (*Node, error) {
 // NetworkPolicy adds a v1.NetworkPolicy resource to the Graph.
 func (g *NetworkingV1Graph) NetworkPolicy(obj *v1.NetworkPolicy) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	g.policies[n.GetUID()] = obj
 
 	selector, err := metav1.LabelSelectorAsSelector(&obj.Spec.PodSelector)
 	if err != nil {
 		return nil, err
 	}
 
-	options := metav1.ListOptions{LabelSelector: selector.String(), FieldSelector: "status.phase=Running"}
-	pods, err := g.graph.clientset.CoreV1().Pods(obj.GetNamespace()).List(context.TODO(), options)
+	pods, err := g.SelectPods(obj.GetNamespace(), nil, selector)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, pod := range pods.Items {
-		p, err := g.graph.CoreV1().Pod(&pod)
-		if err != nil {
-			return nil, err
-		}
+	for _, p := range pods {
 		if len(obj.Spec.Ingress) != 0 {
 			g.Relationship(p, v1.PolicyTypeIngress, n)
 		}
@@ -164,7 +241,7 @@ func (g *NetworkingV1Graph) NetworkPolicy(obj *v1.NetworkPolicy) (*Node, error)
 			rule.From = append(rule.From, v1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}})
 		}
 		for _, peer := range rule.From {
-			_, err := g.NetworkPolicyPeer(obj, v1.PolicyTypeIngress, peer)
+			_, err := g.NetworkPolicyPeer(obj, v1.PolicyTypeIngress, peer, rule.Ports)
 			if err != nil {
 				return nil, err
 			}
@@ -176,7 +253,7 @@ func (g *NetworkingV1Graph) NetworkPolicy(obj *v1.NetworkPolicy) (*Node, error)
 			rule.To = append(rule.To, v1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}})
 		}
 		for _, peer := range rule.To {
-			_, err := g.NetworkPolicyPeer(obj, v1.PolicyTypeEgress, peer)
+			_, err := g.NetworkPolicyPeer(obj, v1.PolicyTypeEgress, peer, rule.Ports)
 			if err != nil {
 				return nil, err
 			}
@@ -187,62 +264,49 @@ func (g *NetworkingV1Graph) NetworkPolicy(obj *v1.NetworkPolicy) (*Node, error)
 }
 
 // NetworkPolicyPeer adds a v1.NetworkPolicyPeer resource to the Graph.
-func (g *NetworkingV1Graph) NetworkPolicyPeer(obj *v1.NetworkPolicy, policyType v1.PolicyType, peer v1.NetworkPolicyPeer) (*Node, error) {
+func (g *NetworkingV1Graph) NetworkPolicyPeer(obj *v1.NetworkPolicy, policyType v1.PolicyType, peer v1.NetworkPolicyPeer, ports []v1.NetworkPolicyPort) (*Node, error) {
 	switch {
 	case peer.NamespaceSelector != nil && peer.PodSelector != nil:
-		return g.NetworkPolicyPeerNamespaceAndPodSelector(obj, policyType, peer)
+		return g.NetworkPolicyPeerNamespaceAndPodSelector(obj, policyType, peer, ports)
 	case peer.NamespaceSelector != nil:
-		return g.NetworkPolicyPeerNamespaceSelector(obj, policyType, peer)
+		return g.NetworkPolicyPeerNamespaceSelector(obj, policyType, peer, ports)
 	case peer.PodSelector != nil:
-		return g.NetworkPolicyPeerPodSelector(obj, policyType, peer)
+		return g.NetworkPolicyPeerPodSelector(obj, policyType, peer, ports)
 	case peer.IPBlock != nil:
-		return g.NetworkPolicyPeerIPBlock(obj, policyType, peer)
+		return g.NetworkPolicyPeerIPBlock(obj, policyType, peer, ports)
 	}
 
 	return nil, nil
 }
 
 // NetworkPolicyPeerNamespaceAndPodSelector adds a v1.NetworkPolicyPeer of type NamespaceAndPodSelector to the Graph.
-func (g *NetworkingV1Graph) NetworkPolicyPeerNamespaceAndPodSelector(obj *v1.NetworkPolicy, policyType v1.PolicyType, peer v1.NetworkPolicyPeer) (*Node, error) {
+func (g *NetworkingV1Graph) NetworkPolicyPeerNamespaceAndPodSelector(obj *v1.NetworkPolicy, policyType v1.PolicyType, peer v1.NetworkPolicyPeer, ports []v1.NetworkPolicyPort) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
 
-	selector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+	namespaceSelector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
 	if err != nil {
 		return nil, err
 	}
 
-	options := metav1.ListOptions{LabelSelector: selector.String()}
-	namespaces, err := g.graph.clientset.CoreV1().Namespaces().List(context.TODO(), options)
+	podSelector, err := metav1.LabelSelectorAsSelector(peer.PodSelector)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, namespace := range namespaces.Items {
-		selector, err := metav1.LabelSelectorAsSelector(peer.PodSelector)
-		if err != nil {
-			return nil, err
-		}
-
-		options := metav1.ListOptions{LabelSelector: selector.String(), FieldSelector: "status.phase=Running"}
-		pods, err := g.graph.clientset.CoreV1().Pods(namespace.GetName()).List(context.TODO(), options)
-		if err != nil {
-			return nil, err
-		}
+	pods, err := g.SelectPods(metav1.NamespaceAll, namespaceSelector, podSelector)
+	if err != nil {
+		return nil, err
+	}
 
-		for _, pod := range pods.Items {
-			p, err := g.graph.CoreV1().Pod(&pod)
-			if err != nil {
-				return nil, err
-			}
-			g.Relationship(n, policyType, p)
-		}
+	for _, p := range pods {
+		g.Rule(n, policyType, p, "Allow", nil, NetworkPolicyPorts(ports))
 	}
 
 	return n, nil
 }
 
 // NetworkPolicyPeerNamespaceSelector adds a v1.NetworkPolicyPeer of type NamespaceSelector to the Graph.
-func (g *NetworkingV1Graph) NetworkPolicyPeerNamespaceSelector(obj *v1.NetworkPolicy, policyType v1.PolicyType, peer v1.NetworkPolicyPeer) (*Node, error) {
+func (g *NetworkingV1Graph) NetworkPolicyPeerNamespaceSelector(obj *v1.NetworkPolicy, policyType v1.PolicyType, peer v1.NetworkPolicyPeer, ports []v1.NetworkPolicyPort) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
 
 	selector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
@@ -250,25 +314,20 @@ func (g *NetworkingV1Graph) NetworkPolicyPeerNamespaceSelector(obj *v1.NetworkPo
 		return nil, err
 	}
 
-	options := metav1.ListOptions{LabelSelector: selector.String()}
-	namespaces, err := g.graph.clientset.CoreV1().Namespaces().List(context.TODO(), options)
+	namespaces, err := g.SelectNamespaces(selector)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, namespace := range namespaces.Items {
-		ns, err := g.graph.CoreV1().Namespace(&namespace)
-		if err != nil {
-			return nil, err
-		}
-		g.Relationship(n, policyType, ns)
+	for _, ns := range namespaces {
+		g.Rule(n, policyType, ns, "Allow", nil, NetworkPolicyPorts(ports))
 	}
 
 	return n, nil
 }
 
 // NetworkPolicyPeerPodSelector adds a v1.NetworkPolicyPeer of type PodSelector to the Graph.
-func (g *NetworkingV1Graph) NetworkPolicyPeerPodSelector(obj *v1.NetworkPolicy, policyType v1.PolicyType, peer v1.NetworkPolicyPeer) (*Node, error) {
+func (g *NetworkingV1Graph) NetworkPolicyPeerPodSelector(obj *v1.NetworkPolicy, policyType v1.PolicyType, peer v1.NetworkPolicyPeer, ports []v1.NetworkPolicyPort) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
 
 	selector, err := metav1.LabelSelectorAsSelector(peer.PodSelector)
@@ -276,45 +335,183 @@ func (g *NetworkingV1Graph) NetworkPolicyPeerPodSelector(obj *v1.NetworkPolicy,
 		return nil, err
 	}
 
-	options := metav1.ListOptions{LabelSelector: selector.String(), FieldSelector: "status.phase=Running"}
-	pods, err := g.graph.clientset.CoreV1().Pods(obj.GetNamespace()).List(context.TODO(), options)
+	pods, err := g.SelectPods(obj.GetNamespace(), nil, selector)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, pod := range pods.Items {
-		p, err := g.graph.CoreV1().Pod(&pod)
-		if err != nil {
-			return nil, err
-		}
-		g.Relationship(n, policyType, p)
+	for _, p := range pods {
+		g.Rule(n, policyType, p, "Allow", nil, NetworkPolicyPorts(ports))
 	}
 
 	return n, nil
 }
 
 // NetworkPolicyPeerIPBlock adds a v1.NetworkPolicyPeer of type IPBlock to the Graph.
-func (g *NetworkingV1Graph) NetworkPolicyPeerIPBlock(obj *v1.NetworkPolicy, policyType v1.PolicyType, peer v1.NetworkPolicyPeer) (*Node, error) {
+func (g *NetworkingV1Graph) NetworkPolicyPeerIPBlock(obj *v1.NetworkPolicy, policyType v1.PolicyType, peer v1.NetworkPolicyPeer, ports []v1.NetworkPolicyPort) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
 
 	i, err := g.IPBlock(peer.IPBlock.CIDR)
 	if err != nil {
 		return nil, err
 	}
-	g.Relationship(n, policyType, i)
+
+	g.Rule(n, policyType, i, "Allow", peer.IPBlock.Except, NetworkPolicyPorts(ports))
 
 	return n, nil
 }
 
 // IPBlock adds a v1.IPBlock resource to the Graph.
 func (g *NetworkingV1Graph) IPBlock(cidr string) (*Node, error) {
+	gvk := schema.FromAPIVersionAndKind(v1.GroupName, "IPBlock")
 	n := g.graph.Node(
-		schema.FromAPIVersionAndKind(v1.GroupName, "IPBlock"),
+		gvk,
 		&metav1.ObjectMeta{
-			UID:  ToUID(cidr),
+			UID:  g.graph.SyntheticUID(gvk, "", cidr),
 			Name: cidr,
 		},
 	)
 
 	return n, nil
 }
+
+// Any adds the peer which matches any network endpoint to the Graph, which is the IPBlock of all IPv4 addresses.
+func (g *NetworkingV1Graph) Any() (*Node, error) {
+	return g.IPBlock("0.0.0.0/0")
+}
+
+// FQDN adds a fully qualified domain name or domain pattern to the Graph.
+func (g *NetworkingV1Graph) FQDN(name string) (*Node, error) {
+	gvk := schema.FromAPIVersionAndKind(v1.GroupName, "FQDN")
+	n := g.graph.Node(
+		gvk,
+		&metav1.ObjectMeta{
+			UID:  g.graph.SyntheticUID(gvk, "", name),
+			Name: name,
+		},
+	)
+
+	return n, nil
+}
+
+// Entity adds a well known network entity like world, cluster or host to the Graph.
+func (g *NetworkingV1Graph) Entity(name string) (*Node, error) {
+	gvk := schema.FromAPIVersionAndKind(v1.GroupName, "Entity")
+	n := g.graph.Node(
+		gvk,
+		&metav1.ObjectMeta{
+			UID:  g.graph.SyntheticUID(gvk, "", name),
+			Name: name,
+		},
+	)
+
+	return n, nil
+}
+
+// SelectNamespaces adds all namespaces matching the selector to the Graph.
+func (g *NetworkingV1Graph) SelectNamespaces(selector labels.Selector) ([]*Node, error) {
+	nodes := []*Node{}
+
+	gvk := corev1.SchemeGroupVersion.WithKind("Namespace")
+	namespaces, err := g.graph.list(gvk, metav1.NamespaceAll, selector)
+	if err != nil {
+		_, err := g.graph.Degrade(gvk, metav1.NamespaceAll, "", err)
+		return nodes, err
+	}
+
+	for _, unstr := range namespaces {
+		namespace := &corev1.Namespace{}
+		if err := FromUnstructured(unstr, namespace); err != nil {
+			return nil, err
+		}
+
+		ns, err := g.graph.CoreV1().Namespace(namespace)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, ns)
+	}
+
+	return nodes, nil
+}
+
+// SelectPods adds all running pods matching the selectors to the Graph. When the namespace selector
+// is nil, only pods in the given namespace are selected and an empty namespace selects all namespaces.
+//
+// For local manifests, pods and workloads with a matching pod template are selected from the objects of the Graph.
+func (g *NetworkingV1Graph) SelectPods(namespace string, namespaceSelector labels.Selector, podSelector labels.Selector) ([]*Node, error) {
+	namespaces := []string{namespace}
+
+	if namespaceSelector != nil {
+		selected, err := g.SelectNamespaces(namespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		namespaces = []string{}
+		for _, ns := range selected {
+			namespaces = append(namespaces, ns.GetName())
+		}
+	}
+
+	nodes := []*Node{}
+	for _, ns := range namespaces {
+		if g.graph.local {
+			for _, target := range g.PolicyTargets(ns) {
+				if podSelector.Matches(target.Labels) {
+					nodes = append(nodes, target.Node)
+				}
+			}
+			continue
+		}
+
+		gvk := corev1.SchemeGroupVersion.WithKind("Pod")
+		pods, err := g.graph.list(gvk, ns, podSelector)
+		if err != nil {
+			if _, err := g.graph.Degrade(gvk, ns, "", err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		for _, unstr := range pods {
+			pod := &corev1.Pod{}
+			if err := FromUnstructured(unstr, pod); err != nil {
+				return nil, err
+			}
+			if pod.Status.Phase != corev1.PodRunning {
+				continue
+			}
+
+			p, err := g.graph.CoreV1().Pod(pod)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, p)
+		}
+	}
+
+	return nodes, nil
+}
+
+// SelectNodes adds all cluster nodes matching the selector to the Graph.
+func (g *NetworkingV1Graph) SelectNodes(selector labels.Selector) ([]*Node, error) {
+	nodes := []*Node{}
+
+	gvk := corev1.SchemeGroupVersion.WithKind("Node")
+	list, err := g.graph.list(gvk, metav1.NamespaceAll, selector)
+	if err != nil {
+		_, err := g.graph.Degrade(gvk, metav1.NamespaceAll, "", err)
+		return nodes, err
+	}
+
+	for _, node := range list {
+		if n, ok := g.graph.Nodes[node.GetUID()]; ok {
+			nodes = append(nodes, n)
+			continue
+		}
+		nodes = append(nodes, g.graph.Node(corev1.SchemeGroupVersion.WithKind("Node"), node))
+	}
+
+	return nodes, nil
+}
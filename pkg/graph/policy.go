@@ -0,0 +1,213 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+
+	"github.com/steveteuber/kubectl-graph/pkg/apis/policy/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func init() {
+	Register(v1alpha1.SchemeGroupVersion.WithKind("AdminNetworkPolicy"), Typed((*Graph).PolicyV1alpha1, (*PolicyV1alpha1Graph).AdminNetworkPolicy))
+	Register(v1alpha1.SchemeGroupVersion.WithKind("BaselineAdminNetworkPolicy"), Typed((*Graph).PolicyV1alpha1, (*PolicyV1alpha1Graph).BaselineAdminNetworkPolicy))
+
+	for _, kind := range []string{"AdminNetworkPolicy", "BaselineAdminNetworkPolicy"} {
+		Depends(v1alpha1.SchemeGroupVersion.WithKind(kind), corev1.SchemeGroupVersion.WithKind("Pod"), corev1.SchemeGroupVersion.WithKind("Namespace"), corev1.SchemeGroupVersion.WithKind("Node"))
+	}
+}
+
+// PolicyV1alpha1Graph is used to graph all admin network policy resources.
+type PolicyV1alpha1Graph struct {
+	graph *Graph
+}
+
+// NewPolicyV1alpha1Graph creates a new PolicyV1alpha1Graph.
+func NewPolicyV1alpha1Graph(g *Graph) *PolicyV1alpha1Graph {
+	return &PolicyV1alpha1Graph{
+		graph: g,
+	}
+}
+
+// PolicyV1alpha1 retrieves the PolicyV1alpha1Graph.
+func (g *Graph) PolicyV1alpha1() *PolicyV1alpha1Graph {
+	return g.policyV1alpha1
+}
+
+// AdminNetworkPolicy adds a v1alpha1.AdminNetworkPolicy resource to the Graph.
+func (g *PolicyV1alpha1Graph) AdminNetworkPolicy(obj *v1alpha1.AdminNetworkPolicy) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.Rules(n, obj.Spec.Subject, obj.Spec.Ingress, obj.Spec.Egress)
+}
+
+// BaselineAdminNetworkPolicy adds a v1alpha1.BaselineAdminNetworkPolicy resource to the Graph.
+func (g *PolicyV1alpha1Graph) BaselineAdminNetworkPolicy(obj *v1alpha1.BaselineAdminNetworkPolicy) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.Rules(n, obj.Spec.Subject, obj.Spec.Ingress, obj.Spec.Egress)
+}
+
+// Rules adds the subject and all ingress and egress peers of a policy to the Graph.
+func (g *PolicyV1alpha1Graph) Rules(n *Node, subject v1alpha1.Subject, ingress []v1alpha1.IngressRule, egress []v1alpha1.EgressRule) error {
+	networking := g.graph.NetworkingV1()
+
+	pods, err := g.Pods(subject.Namespaces, subject.Pods)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if len(ingress) != 0 {
+			networking.Relationship(pod, networkingv1.PolicyTypeIngress, n)
+		}
+		if len(egress) != 0 {
+			networking.Relationship(pod, networkingv1.PolicyTypeEgress, n)
+		}
+	}
+
+	for _, rule := range ingress {
+		for _, peer := range rule.From {
+			pods, err := g.Pods(peer.Namespaces, peer.Pods)
+			if err != nil {
+				return err
+			}
+
+			for _, pod := range pods {
+				g.Relationship(n, networkingv1.PolicyTypeIngress, rule.Action, pod, rule.Ports)
+			}
+		}
+	}
+
+	for _, rule := range egress {
+		for _, peer := range rule.To {
+			peers, err := g.EgressPeer(peer)
+			if err != nil {
+				return err
+			}
+
+			for _, p := range peers {
+				g.Relationship(n, networkingv1.PolicyTypeEgress, rule.Action, p, rule.Ports)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Relationship creates a new policy relationship with the action and the allowed ports of a rule.
+func (g *PolicyV1alpha1Graph) Relationship(from *Node, policyType networkingv1.PolicyType, action string, to *Node, ports *[]v1alpha1.Port) *Relationship {
+	formatted := []string{}
+	if ports != nil {
+		formatted = AdminNetworkPolicyPorts(*ports)
+	}
+
+	return g.graph.NetworkingV1().Rule(from, policyType, to, action, nil, formatted)
+}
+
+// EgressPeer adds all pods, nodes, networks and domain names of a v1alpha1.EgressPeer to the Graph.
+func (g *PolicyV1alpha1Graph) EgressPeer(peer v1alpha1.EgressPeer) ([]*Node, error) {
+	networking := g.graph.NetworkingV1()
+
+	peers, err := g.Pods(peer.Namespaces, peer.Pods)
+	if err != nil {
+		return nil, err
+	}
+
+	if peer.Nodes != nil {
+		selector, err := metav1.LabelSelectorAsSelector(peer.Nodes)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes, err := networking.SelectNodes(selector)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, nodes...)
+	}
+
+	for _, cidr := range peer.Networks {
+		i, err := networking.IPBlock(cidr)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, i)
+	}
+
+	for _, domain := range peer.DomainNames {
+		f, err := networking.FQDN(domain)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, f)
+	}
+
+	return peers, nil
+}
+
+// Pods adds all pods selected either by namespaces or by v1alpha1.NamespacedPod to the Graph.
+func (g *PolicyV1alpha1Graph) Pods(namespaces *metav1.LabelSelector, pods *v1alpha1.NamespacedPod) ([]*Node, error) {
+	var namespaceSelector, podSelector labels.Selector
+	var err error
+
+	switch {
+	case namespaces != nil:
+		namespaceSelector, err = metav1.LabelSelectorAsSelector(namespaces)
+		if err != nil {
+			return nil, err
+		}
+		podSelector = labels.Everything()
+	case pods != nil:
+		namespaceSelector, err = metav1.LabelSelectorAsSelector(&pods.NamespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+		podSelector, err = metav1.LabelSelectorAsSelector(&pods.PodSelector)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return []*Node{}, nil
+	}
+
+	return g.graph.NetworkingV1().SelectPods(metav1.NamespaceAll, namespaceSelector, podSelector)
+}
+
+// AdminNetworkPolicyPorts formats a list of v1alpha1.Port as protocol/port strings.
+func AdminNetworkPolicyPorts(ports []v1alpha1.Port) []string {
+	formatted := []string{}
+
+	for _, port := range ports {
+		switch {
+		case port.PortNumber != nil:
+			formatted = append(formatted, fmt.Sprintf("%s/%d", port.PortNumber.Protocol, port.PortNumber.Port))
+		case port.PortRange != nil:
+			protocol := port.PortRange.Protocol
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+			formatted = append(formatted, fmt.Sprintf("%s/%d-%d", protocol, port.PortRange.Start, port.PortRange.End))
+		case port.NamedPort != nil:
+			formatted = append(formatted, fmt.Sprintf("%s/%s", corev1.ProtocolTCP, *port.NamedPort))
+		}
+	}
+
+	return formatted
+}
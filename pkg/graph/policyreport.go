@@ -0,0 +1,137 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"strings"
+
+	kyvernov1 "github.com/steveteuber/kubectl-graph/pkg/apis/kyverno/v1"
+	"github.com/steveteuber/kubectl-graph/pkg/apis/wgpolicyk8s/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	Register(v1alpha2.SchemeGroupVersion.WithKind("PolicyReport"), Typed((*Graph).Wgpolicyk8sV1alpha2, (*Wgpolicyk8sV1alpha2Graph).PolicyReport))
+	Register(v1alpha2.SchemeGroupVersion.WithKind("ClusterPolicyReport"), Typed((*Graph).Wgpolicyk8sV1alpha2, (*Wgpolicyk8sV1alpha2Graph).ClusterPolicyReport))
+}
+
+// Wgpolicyk8sV1alpha2Graph is used to graph all policy report resources.
+type Wgpolicyk8sV1alpha2Graph struct {
+	graph *Graph
+}
+
+// NewWgpolicyk8sV1alpha2Graph creates a new Wgpolicyk8sV1alpha2Graph.
+func NewWgpolicyk8sV1alpha2Graph(g *Graph) *Wgpolicyk8sV1alpha2Graph {
+	return &Wgpolicyk8sV1alpha2Graph{
+		graph: g,
+	}
+}
+
+// Wgpolicyk8sV1alpha2 retrieves the Wgpolicyk8sV1alpha2Graph.
+func (g *Graph) Wgpolicyk8sV1alpha2() *Wgpolicyk8sV1alpha2Graph {
+	return g.wgpolicyk8sV1alpha2
+}
+
+// PolicyReport adds a v1alpha2.PolicyReport resource to the Graph.
+func (g *Wgpolicyk8sV1alpha2Graph) PolicyReport(obj *v1alpha2.PolicyReport) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.Results(obj.GetNamespace(), obj.Scope, obj.Results)
+}
+
+// ClusterPolicyReport adds a v1alpha2.ClusterPolicyReport resource to the Graph.
+func (g *Wgpolicyk8sV1alpha2Graph) ClusterPolicyReport(obj *v1alpha2.ClusterPolicyReport) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	return n, g.Results(metav1.NamespaceNone, obj.Scope, obj.Results)
+}
+
+// Results adds a Violation relationship from every resource to the policy of each failed result.
+// Results without resources refer to the scope of the report.
+func (g *Wgpolicyk8sV1alpha2Graph) Results(namespace string, scope *corev1.ObjectReference, results []v1alpha2.PolicyReportResult) error {
+	for _, result := range results {
+		switch result.Result {
+		case "fail", "warn", "error":
+		default:
+			continue
+		}
+
+		resources := result.Resources
+		if len(resources) == 0 && scope != nil {
+			resources = []corev1.ObjectReference{*scope}
+		}
+
+		policy := g.Policy(namespace, result)
+		for _, ref := range resources {
+			resource, ok := g.graph.Nodes[ref.UID]
+			if !ok {
+				resource = g.graph.Ref(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind), ref.Namespace, ref.Name)
+			}
+
+			scope := map[string]string{}
+			if result.Rule != "" {
+				scope["rule"] = result.Rule
+			}
+
+			r := g.Violation(resource, policy, result.Result, scope)
+			if result.Severity != "" {
+				r.Attribute("severity", result.Severity)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Policy resolves the policy of a result. Kyverno policies are resolved to existing nodes, where a Policy in
+// the namespace of the report takes precedence over a ClusterPolicy with the same name. Other sources get a
+// node with the name of the policy.
+func (g *Wgpolicyk8sV1alpha2Graph) Policy(namespace string, result v1alpha2.PolicyReportResult) *Node {
+	if result.Source != "" && result.Source != "kyverno" {
+		gvk := schema.FromAPIVersionAndKind("kubectl-graph/v1", "Policy")
+		return g.graph.Node(
+			gvk,
+			&metav1.ObjectMeta{
+				UID:  g.graph.SyntheticUID(gvk, "", result.Source+"/"+result.Policy),
+				Name: result.Policy,
+			},
+		)
+	}
+
+	if ns, name, ok := strings.Cut(result.Policy, "/"); ok {
+		return g.graph.Ref(kyvernov1.SchemeGroupVersion.WithKind("Policy"), ns, name)
+	}
+
+	if namespace != metav1.NamespaceNone {
+		if n := g.graph.FindNode(kyvernov1.SchemeGroupVersion.String(), "Policy", namespace, result.Policy); n != nil {
+			return n
+		}
+	}
+	if n := g.graph.FindNode(kyvernov1.SchemeGroupVersion.String(), "ClusterPolicy", metav1.NamespaceNone, result.Policy); n != nil {
+		return n
+	}
+
+	return g.graph.Ref(kyvernov1.SchemeGroupVersion.WithKind("ClusterPolicy"), metav1.NamespaceNone, result.Policy)
+}
+
+// Violation creates a new relationship from a resource to the policy which it violates. The scope, like the
+// rule of the policy, keeps the violations of different rules apart.
+func (g *Wgpolicyk8sV1alpha2Graph) Violation(resource *Node, policy *Node, result string, scope map[string]string) *Relationship {
+	return g.graph.ScopedRelationship(resource, "Violation", policy, scope).
+		Attribute("result", result).
+		Attribute("color", "#ea4335")
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPolicyReportNamespacedPolicy(t *testing.T) {
+	objs := testObjects(t, `
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: require-labels
+  uid: clusterpolicy-require-labels
+---
+apiVersion: kyverno.io/v1
+kind: Policy
+metadata:
+  name: require-labels
+  namespace: shop
+  uid: policy-require-labels
+---
+apiVersion: wgpolicyk8s.io/v1alpha2
+kind: PolicyReport
+metadata:
+  name: shop
+  namespace: shop
+  uid: report-shop
+results:
+  - policy: require-labels
+    rule: check-team
+    result: fail
+    source: kyverno
+    resources:
+      - apiVersion: v1
+        kind: Pod
+        name: web-1
+        namespace: shop
+        uid: pod-web-1
+`)
+
+	g, err := NewGraph(context.Background(), nil, objs, &Options{Concurrency: 1}, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Pod/web-1 Violation Policy/require-labels"
+	for _, edge := range testEdges(g) {
+		if strings.HasPrefix(edge, want) {
+			return
+		}
+	}
+	t.Errorf("relationships = %q, want %q", testEdges(g), want)
+}
@@ -0,0 +1,573 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// PortSet is a set of allowed ports. A nil PortSet allows all ports.
+type PortSet []v1.NetworkPolicyPort
+
+// ReachabilityMatrix contains which nodes can reach each other and on which ports.
+type ReachabilityMatrix struct {
+	Nodes []*Node
+	Ports map[types.UID]map[types.UID]PortSet
+}
+
+// PolicyTarget is a pod, or a workload with a pod template, which can be selected by network policies.
+type PolicyTarget struct {
+	Node   *Node
+	Labels labels.Set
+}
+
+// Reachability evaluates all network policies in the Graph and adds a CanReach relationship between
+// all running pods which are allowed to communicate with each other. Pods which are not selected by any policy
+// are not isolated, like in Kubernetes. When workloads is true, pods are aggregated to their top level owner.
+func (g *NetworkingV1Graph) Reachability(workloads bool) (*ReachabilityMatrix, error) {
+	pods := []PolicyTarget{}
+	if g.graph.local {
+		pods = g.PolicyTargets(metav1.NamespaceAll)
+	} else {
+		for _, node := range g.graph.NodesByKind(corev1.SchemeGroupVersion.WithKind("Pod").GroupKind(), metav1.NamespaceAll) {
+			// pods which are not running have no IP, or their IP is already reused by another pod
+			if node.Namespace == "" || node.Status == nil || node.Status.Phase != string(corev1.PodRunning) {
+				continue
+			}
+			if workloads {
+				if err := g.graph.ResolveOwners(node); err != nil {
+					return nil, err
+				}
+			}
+			pods = append(pods, PolicyTarget{Node: node, Labels: labels.Set(node.GetLabels())})
+		}
+	}
+
+	matrix := &ReachabilityMatrix{
+		Nodes: []*Node{},
+		Ports: make(map[types.UID]map[types.UID]PortSet),
+	}
+
+	targets := make(map[types.UID]*Node)
+	for _, pod := range pods {
+		target := pod.Node
+		if workloads {
+			target = g.graph.Workload(pod.Node)
+		}
+		targets[pod.Node.UID] = target
+
+		if _, ok := matrix.Ports[target.UID]; !ok {
+			matrix.Nodes = append(matrix.Nodes, target)
+			matrix.Ports[target.UID] = make(map[types.UID]PortSet)
+		}
+	}
+
+	sort.Slice(matrix.Nodes, func(i, j int) bool {
+		a, b := matrix.Nodes[i], matrix.Nodes[j]
+		if a.Namespace+"/"+a.Name != b.Namespace+"/"+b.Name {
+			return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+		}
+		return CompareNodes(a, b) < 0
+	})
+
+	for _, from := range pods {
+		for _, to := range pods {
+			if from.Node.UID == to.Node.UID {
+				continue
+			}
+
+			egress, ok, err := g.Allowed(v1.PolicyTypeEgress, from, to)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			ingress, ok, err := g.Allowed(v1.PolicyTypeIngress, to, from)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			ports, ok := egress.Intersect(ingress)
+			if !ok {
+				continue
+			}
+
+			source, target := targets[from.Node.UID], targets[to.Node.UID]
+			if existing, ok := matrix.Ports[source.UID][target.UID]; ok {
+				ports = existing.Union(ports)
+			}
+			matrix.Ports[source.UID][target.UID] = ports
+		}
+	}
+
+	for _, from := range matrix.Nodes {
+		for _, to := range matrix.Nodes {
+			ports, ok := matrix.Ports[from.UID][to.UID]
+			// replicas of the same workload which can reach each other are only shown in the matrix
+			if !ok || from.UID == to.UID {
+				continue
+			}
+
+			r := g.graph.Relationship(from, "CanReach", to).Attribute("color", "#4285f4")
+			g.Ports(r, NetworkPolicyPorts(ports))
+		}
+	}
+
+	return matrix, nil
+}
+
+// PolicyTargets returns all pods and workloads with a pod template from the objects of the Graph.
+// An empty namespace returns the targets of all namespaces.
+//
+// Manifests may contain a workload together with the objects it owns, like the ReplicaSets and Pods of a
+// Deployment. They are the same target, so only the top level owner is returned, or the first owned object
+// when the owner has no pod template of its own.
+func (g *NetworkingV1Graph) PolicyTargets(namespace string) []PolicyTarget {
+	targets := []PolicyTarget{}
+	workloads := make(map[types.UID]int)
+
+	for _, obj := range g.graph.objects {
+		if namespace != metav1.NamespaceAll && obj.GetNamespace() != namespace {
+			continue
+		}
+
+		podLabels, ok := PodTemplateLabels(obj)
+		if !ok {
+			continue
+		}
+
+		node, ok := g.graph.Nodes[obj.GetUID()]
+		if !ok {
+			continue
+		}
+
+		target := PolicyTarget{Node: node, Labels: labels.Set(podLabels)}
+		workload := g.graph.Workload(node)
+		if i, ok := workloads[workload.UID]; ok {
+			if node.UID == workload.UID {
+				targets[i] = target
+			}
+			continue
+		}
+
+		workloads[workload.UID] = len(targets)
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// podTemplatePaths are the paths to the pod template of all workloads, a pod is its own template.
+var podTemplatePaths = map[string][]string{
+	"Pod":                   {},
+	"Deployment":            {"spec", "template"},
+	"DeploymentConfig":      {"spec", "template"},
+	"DaemonSet":             {"spec", "template"},
+	"Job":                   {"spec", "template"},
+	"ReplicaSet":            {"spec", "template"},
+	"ReplicationController": {"spec", "template"},
+	"StatefulSet":           {"spec", "template"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template"},
+}
+
+// PodTemplateLabels returns the labels of a pod, or the labels of the pod template of a workload.
+func PodTemplateLabels(obj *unstructured.Unstructured) (map[string]string, bool) {
+	path, ok := podTemplatePaths[obj.GetKind()]
+	if !ok {
+		return nil, false
+	}
+
+	podLabels, _, err := unstructured.NestedStringMap(obj.Object, append(append([]string{}, path...), "metadata", "labels")...)
+	if err != nil {
+		return nil, false
+	}
+
+	return podLabels, true
+}
+
+// PodTemplateSpec returns the spec of a pod, or the spec of the pod template of a workload.
+func PodTemplateSpec(obj *unstructured.Unstructured) (*corev1.PodSpec, bool) {
+	path, ok := podTemplatePaths[obj.GetKind()]
+	if !ok {
+		return nil, false
+	}
+
+	fields, found, err := unstructured.NestedMap(obj.Object, append(append([]string{}, path...), "spec")...)
+	if err != nil || !found {
+		return nil, false
+	}
+
+	spec := &corev1.PodSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(fields, spec); err != nil {
+		return nil, false
+	}
+
+	return spec, true
+}
+
+// Allowed returns the ports on which the pod allows traffic of the given policy type to or from the peer.
+// The second return value is false when no traffic is allowed at all.
+func (g *NetworkingV1Graph) Allowed(policyType v1.PolicyType, pod PolicyTarget, peer PolicyTarget) (PortSet, bool, error) {
+	isolated := false
+	allowed := PortSet{}
+	matched := false
+
+	for _, policy := range g.policies {
+		if policy.GetNamespace() != pod.Node.GetNamespace() || !PolicyTypes(policy)[policyType] {
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+		if err != nil {
+			return nil, false, err
+		}
+		if !selector.Matches(pod.Labels) {
+			continue
+		}
+		isolated = true
+
+		type rule struct {
+			peers []v1.NetworkPolicyPeer
+			ports []v1.NetworkPolicyPort
+		}
+
+		rules := []rule{}
+		switch policyType {
+		case v1.PolicyTypeIngress:
+			for _, ingress := range policy.Spec.Ingress {
+				rules = append(rules, rule{peers: ingress.From, ports: ingress.Ports})
+			}
+		case v1.PolicyTypeEgress:
+			for _, egress := range policy.Spec.Egress {
+				rules = append(rules, rule{peers: egress.To, ports: egress.Ports})
+			}
+		}
+
+		for _, r := range rules {
+			ok, err := g.PeersMatch(policy, r.peers, peer)
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				continue
+			}
+
+			if len(r.ports) == 0 {
+				return nil, true, nil
+			}
+			matched = true
+			allowed = allowed.Union(r.ports)
+		}
+	}
+
+	if !isolated {
+		return nil, true, nil
+	}
+
+	return allowed, matched, nil
+}
+
+// PeersMatch returns true when any of the peers of a policy rule selects the pod. An empty list of peers selects all pods.
+func (g *NetworkingV1Graph) PeersMatch(policy *v1.NetworkPolicy, peers []v1.NetworkPolicyPeer, pod PolicyTarget) (bool, error) {
+	if len(peers) == 0 {
+		return true, nil
+	}
+
+	for _, peer := range peers {
+		if peer.PodSelector == nil && peer.NamespaceSelector == nil {
+			continue
+		}
+
+		if peer.NamespaceSelector == nil && pod.Node.GetNamespace() != policy.GetNamespace() {
+			continue
+		}
+
+		if peer.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+			if err != nil {
+				return false, err
+			}
+
+			namespaceLabels, err := g.NamespaceLabels(pod.Node.GetNamespace())
+			if err != nil {
+				return false, err
+			}
+			if !selector.Matches(namespaceLabels) {
+				continue
+			}
+		}
+
+		if peer.PodSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(peer.PodSelector)
+			if err != nil {
+				return false, err
+			}
+			if !selector.Matches(pod.Labels) {
+				continue
+			}
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// NamespaceLabels returns the labels of a namespace including the immutable kubernetes.io/metadata.name label.
+func (g *NetworkingV1Graph) NamespaceLabels(name string) (labels.Set, error) {
+	if namespaceLabels, ok := g.namespaces[name]; ok {
+		return namespaceLabels, nil
+	}
+
+	namespaceLabels := labels.Set{corev1.LabelMetadataName: name}
+
+	gvk := corev1.SchemeGroupVersion.WithKind("Namespace")
+	namespace, err := g.graph.get(gvk, "", name)
+	switch {
+	case err == nil:
+		for key, value := range namespace.GetLabels() {
+			namespaceLabels[key] = value
+		}
+	case !apierrors.IsNotFound(err):
+		if _, err := g.graph.Degrade(gvk, "", name, err); err != nil {
+			return nil, err
+		}
+	}
+	g.namespaces[name] = namespaceLabels
+
+	return namespaceLabels, nil
+}
+
+// PolicyTypes returns the effective policy types of a network policy.
+func PolicyTypes(policy *v1.NetworkPolicy) map[v1.PolicyType]bool {
+	types := make(map[v1.PolicyType]bool)
+
+	for _, policyType := range policy.Spec.PolicyTypes {
+		types[policyType] = true
+	}
+
+	if len(types) == 0 {
+		types[v1.PolicyTypeIngress] = true
+		if len(policy.Spec.Egress) != 0 {
+			types[v1.PolicyTypeEgress] = true
+		}
+	}
+
+	return types
+}
+
+// Workload returns the top level owner of a node, or the node itself when it has no owner in the Graph.
+// When owners refer to each other in a cycle, the last owner before the cycle repeats is returned.
+func (g *Graph) Workload(n *Node) *Node {
+	visited := make(map[types.UID]bool)
+	for {
+		visited[n.UID] = true
+
+		var owner *Node
+		for _, ownerRef := range n.GetOwnerReferences() {
+			if o, ok := g.Nodes[ownerRef.UID]; ok {
+				owner = o
+				break
+			}
+		}
+
+		if owner == nil || visited[owner.UID] {
+			return n
+		}
+		n = owner
+	}
+}
+
+// ResolveOwners adds the owners of a node up to its top level owner to the Graph. Owners which were only added as
+// placeholder for the owner reference of an object are retrieved from the ObjectSource, so their own owners are
+// known as well, like the Deployment of the ReplicaSet of a Pod.
+func (g *Graph) ResolveOwners(n *Node) error {
+	visited := make(map[types.UID]bool)
+	for n != nil && !visited[n.UID] {
+		visited[n.UID] = true
+
+		var owner *Node
+		for _, ownerRef := range n.GetOwnerReferences() {
+			o, ok := g.Nodes[ownerRef.UID]
+			if !ok {
+				continue
+			}
+
+			if o.Status == nil && !g.local {
+				gvk := schema.FromAPIVersionAndKind(ownerRef.APIVersion, ownerRef.Kind)
+				unstr, err := g.get(gvk, n.GetNamespace(), ownerRef.Name)
+				if err != nil {
+					if _, err := g.Degrade(gvk, n.GetNamespace(), ownerRef.Name, err); err != nil {
+						return err
+					}
+				} else {
+					o = g.Node(unstr.GroupVersionKind(), unstr)
+				}
+			}
+
+			owner = o
+			break
+		}
+
+		n = owner
+	}
+
+	return nil
+}
+
+// Union returns all ports of both sets.
+func (s PortSet) Union(other PortSet) PortSet {
+	if s == nil || other == nil {
+		return nil
+	}
+
+	union := append(PortSet{}, s...)
+	seen := make(map[string]bool)
+	for _, port := range NetworkPolicyPorts(union) {
+		seen[port] = true
+	}
+
+	for _, port := range other {
+		formatted := NetworkPolicyPorts([]v1.NetworkPolicyPort{port})[0]
+		if !seen[formatted] {
+			seen[formatted] = true
+			union = append(union, port)
+		}
+	}
+
+	return union
+}
+
+// Intersect returns all ports which are contained in both sets.
+// The second return value is false when the intersection is empty.
+func (s PortSet) Intersect(other PortSet) (PortSet, bool) {
+	if s == nil {
+		return other, other == nil || len(other) != 0
+	}
+	if other == nil {
+		return s, len(s) != 0
+	}
+
+	intersection := PortSet{}
+	for _, a := range s {
+		for _, b := range other {
+			if port, ok := IntersectPort(a, b); ok {
+				intersection = intersection.Union(PortSet{port})
+			}
+		}
+	}
+
+	return intersection, len(intersection) != 0
+}
+
+// IntersectPort returns the overlap of two network policy ports. Named ports can not be resolved
+// without the container specification, so they are assumed to overlap with any numeric port.
+func IntersectPort(a v1.NetworkPolicyPort, b v1.NetworkPolicyPort) (v1.NetworkPolicyPort, bool) {
+	protocol := func(port v1.NetworkPolicyPort) corev1.Protocol {
+		if port.Protocol == nil {
+			return corev1.ProtocolTCP
+		}
+		return *port.Protocol
+	}
+
+	if protocol(a) != protocol(b) {
+		return v1.NetworkPolicyPort{}, false
+	}
+
+	switch {
+	case a.Port == nil:
+		return b, true
+	case b.Port == nil:
+		return a, true
+	case a.Port.Type == intstr.String && b.Port.Type == intstr.String:
+		return a, a.Port.StrVal == b.Port.StrVal
+	case a.Port.Type == intstr.String:
+		return a, true
+	case b.Port.Type == intstr.String:
+		return b, true
+	}
+
+	portRange := func(port v1.NetworkPolicyPort) (int32, int32) {
+		if port.EndPort != nil {
+			return port.Port.IntVal, *port.EndPort
+		}
+		return port.Port.IntVal, port.Port.IntVal
+	}
+
+	aStart, aEnd := portRange(a)
+	bStart, bEnd := portRange(b)
+
+	start, end := max(aStart, bStart), min(aEnd, bEnd)
+	if start > end {
+		return v1.NetworkPolicyPort{}, false
+	}
+
+	p := protocol(a)
+	port := v1.NetworkPolicyPort{Protocol: &p, Port: &intstr.IntOrString{Type: intstr.Int, IntVal: start}}
+	if start != end {
+		port.EndPort = &end
+	}
+
+	return port, true
+}
+
+// Write prints the reachability matrix as table with sources as rows and destinations as columns.
+func (m *ReachabilityMatrix) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	header := []string{"FROM \\ TO"}
+	for _, to := range m.Nodes {
+		header = append(header, fmt.Sprintf("%s/%s", to.Namespace, to.Name))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, from := range m.Nodes {
+		row := []string{fmt.Sprintf("%s/%s", from.Namespace, from.Name)}
+		for _, to := range m.Nodes {
+			ports, ok := m.Ports[from.UID][to.UID]
+			switch {
+			case !ok:
+				row = append(row, "-")
+			case ports == nil:
+				row = append(row, "all")
+			default:
+				row = append(row, strings.Join(NetworkPolicyPorts(ports), ","))
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,420 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const reachabilityPods = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+  uid: namespace-shop
+  labels:
+    team: checkout
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: shop
+  uid: pod-web
+  labels:
+    app: web
+status:
+  phase: Running
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: api
+  namespace: shop
+  uid: pod-api
+  labels:
+    app: api
+status:
+  phase: Running
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: job
+  namespace: batch
+  uid: pod-job
+  labels:
+    app: job
+status:
+  phase: Running
+`
+
+// testMatrix returns the reachable pairs of the matrix as "namespace/name -> namespace/name: ports".
+func testMatrix(m *ReachabilityMatrix) []string {
+	pairs := []string{}
+	for _, from := range m.Nodes {
+		for _, to := range m.Nodes {
+			ports, ok := m.Ports[from.UID][to.UID]
+			if !ok {
+				continue
+			}
+
+			allowed := "all"
+			if ports != nil {
+				allowed = strings.Join(NetworkPolicyPorts(ports), ",")
+			}
+			pairs = append(pairs, from.Namespace+"/"+from.Name+" -> "+to.Namespace+"/"+to.Name+": "+allowed)
+		}
+	}
+
+	return pairs
+}
+
+func TestReachability(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies string
+		want     []string
+	}{
+		{
+			name: "no policy allows all",
+			want: []string{
+				"batch/job -> shop/api: all",
+				"batch/job -> shop/web: all",
+				"shop/api -> batch/job: all",
+				"shop/api -> shop/web: all",
+				"shop/web -> batch/job: all",
+				"shop/web -> shop/api: all",
+			},
+		},
+		{
+			name: "default deny ingress",
+			policies: `
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+  namespace: shop
+  uid: policy-default-deny
+spec:
+  podSelector: {}
+  policyTypes:
+    - Ingress
+`,
+			want: []string{
+				"shop/api -> batch/job: all",
+				"shop/web -> batch/job: all",
+			},
+		},
+		{
+			name: "egress only",
+			policies: `
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: web-egress
+  namespace: shop
+  uid: policy-web-egress
+spec:
+  podSelector:
+    matchLabels:
+      app: web
+  policyTypes:
+    - Egress
+  egress:
+    - to:
+        - podSelector:
+            matchLabels:
+              app: api
+      ports:
+        - port: 8080
+`,
+			want: []string{
+				"batch/job -> shop/api: all",
+				"batch/job -> shop/web: all",
+				"shop/api -> batch/job: all",
+				"shop/api -> shop/web: all",
+				"shop/web -> shop/api: TCP/8080",
+			},
+		},
+		{
+			name: "namespace selector",
+			policies: `
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: api-ingress
+  namespace: shop
+  uid: policy-api-ingress
+spec:
+  podSelector:
+    matchLabels:
+      app: api
+  ingress:
+    - from:
+        - namespaceSelector:
+            matchLabels:
+              team: checkout
+      ports:
+        - port: 8080
+          endPort: 8090
+`,
+			want: []string{
+				"batch/job -> shop/web: all",
+				"shop/api -> batch/job: all",
+				"shop/api -> shop/web: all",
+				"shop/web -> batch/job: all",
+				"shop/web -> shop/api: TCP/8080-8090",
+			},
+		},
+		{
+			name: "named ports",
+			policies: `
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: api-ingress
+  namespace: shop
+  uid: policy-api-ingress
+spec:
+  podSelector:
+    matchLabels:
+      app: api
+  ingress:
+    - ports:
+        - port: http
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: web-egress
+  namespace: shop
+  uid: policy-web-egress
+spec:
+  podSelector:
+    matchLabels:
+      app: web
+  policyTypes:
+    - Egress
+  egress:
+    - ports:
+        - port: 8080
+        - port: metrics
+          protocol: UDP
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: job-egress
+  namespace: batch
+  uid: policy-job-egress
+spec:
+  podSelector: {}
+  policyTypes:
+    - Egress
+  egress:
+    - ports:
+        - port: metrics
+`,
+			want: []string{
+				"batch/job -> shop/web: TCP/metrics",
+				"shop/api -> batch/job: all",
+				"shop/api -> shop/web: all",
+				"shop/web -> batch/job: TCP/8080,UDP/metrics",
+				"shop/web -> shop/api: TCP/http",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := testObjects(t, reachabilityPods)
+			if tt.policies != "" {
+				objs = append(objs, testObjects(t, tt.policies)...)
+			}
+
+			g, err := NewGraph(context.Background(), nil, objs, &Options{Concurrency: 1}, func() {})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := g.NetworkingV1().Reachability(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := testMatrix(m); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matrix = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReachabilityWorkloads(t *testing.T) {
+	objs := testObjects(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+  uid: deployment-web
+  creationTimestamp: "2026-01-01T00:00:00Z"
+---
+apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: web-5d4f
+  namespace: shop
+  uid: replicaset-web
+  creationTimestamp: "2026-01-01T00:00:00Z"
+  ownerReferences:
+    - apiVersion: apps/v1
+      kind: Deployment
+      name: web
+      uid: deployment-web
+      controller: true
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-5d4f-a
+  namespace: shop
+  uid: pod-web-a
+  labels:
+    app: web
+  ownerReferences:
+    - apiVersion: apps/v1
+      kind: ReplicaSet
+      name: web-5d4f
+      uid: replicaset-web
+      controller: true
+status:
+  phase: Running
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-5d4f-b
+  namespace: shop
+  uid: pod-web-b
+  labels:
+    app: web
+  ownerReferences:
+    - apiVersion: apps/v1
+      kind: ReplicaSet
+      name: web-5d4f
+      uid: replicaset-web
+      controller: true
+status:
+  phase: Running
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-5d4f-c
+  namespace: shop
+  uid: pod-web-c
+  labels:
+    app: web
+  ownerReferences:
+    - apiVersion: apps/v1
+      kind: ReplicaSet
+      name: web-5d4f
+      uid: replicaset-web
+      controller: true
+status:
+  phase: Succeeded
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: api
+  namespace: shop
+  uid: pod-api
+  labels:
+    app: api
+status:
+  phase: Running
+`)
+
+	tests := []struct {
+		name      string
+		workloads bool
+		want      []string
+		edges     []string
+	}{
+		{
+			name: "pods",
+			want: []string{
+				"shop/api -> shop/web-5d4f-a: all",
+				"shop/api -> shop/web-5d4f-b: all",
+				"shop/web-5d4f-a -> shop/api: all",
+				"shop/web-5d4f-a -> shop/web-5d4f-b: all",
+				"shop/web-5d4f-b -> shop/api: all",
+				"shop/web-5d4f-b -> shop/web-5d4f-a: all",
+			},
+			edges: []string{
+				"Pod/api CanReach Pod/web-5d4f-a ports=*",
+				"Pod/api CanReach Pod/web-5d4f-b ports=*",
+				"Pod/web-5d4f-a CanReach Pod/api ports=*",
+				"Pod/web-5d4f-a CanReach Pod/web-5d4f-b ports=*",
+				"Pod/web-5d4f-b CanReach Pod/api ports=*",
+				"Pod/web-5d4f-b CanReach Pod/web-5d4f-a ports=*",
+			},
+		},
+		{
+			name:      "workloads",
+			workloads: true,
+			want: []string{
+				"shop/api -> shop/web: all",
+				"shop/web -> shop/api: all",
+				"shop/web -> shop/web: all",
+			},
+			edges: []string{
+				"Deployment/web CanReach Pod/api ports=*",
+				"Pod/api CanReach Deployment/web ports=*",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGraph(context.Background(), &remoteSource{NewMemorySource(objs)}, objs[2:], &Options{Concurrency: 1}, func() {})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := g.NetworkingV1().Reachability(tt.workloads)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := testMatrix(m); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matrix = %q, want %q", got, tt.want)
+			}
+
+			edges := []string{}
+			for _, edge := range testEdges(g) {
+				if strings.Contains(edge, " CanReach ") {
+					edges = append(edges, edge)
+				}
+			}
+			if !reflect.DeepEqual(edges, tt.edges) {
+				t.Errorf("relationships = %q, want %q", edges, tt.edges)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	resolversMu  sync.RWMutex
+	resolvers    = map[schema.GroupVersionKind]Resolver{}
+	dependencies = map[schema.GroupVersionKind][]schema.GroupVersionKind{}
+)
+
+// Resolver adds an unstructured object and the relationships to its related objects to the Graph.
+type Resolver interface {
+	Resolve(g *Graph, unstr *unstructured.Unstructured) (*Node, error)
+}
+
+// ResolverFunc is an adapter to allow the use of ordinary functions as Resolver.
+type ResolverFunc func(g *Graph, unstr *unstructured.Unstructured) (*Node, error)
+
+// Resolve calls f(g, unstr).
+func (f ResolverFunc) Resolve(g *Graph, unstr *unstructured.Unstructured) (*Node, error) {
+	return f(g, unstr)
+}
+
+// Register makes a Resolver available for the given group, version and kind and replaces any
+// previously registered Resolver. A Resolver registered with an empty kind resolves all kinds of
+// the group version which have no Resolver of their own.
+func Register(gvk schema.GroupVersionKind, r Resolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+
+	resolvers[gvk] = r
+}
+
+// Lookup returns the Resolver for the given group, version and kind, or nil if there is none.
+func Lookup(gvk schema.GroupVersionKind) Resolver {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+
+	if r, ok := resolvers[gvk]; ok {
+		return r
+	}
+
+	return resolvers[gvk.GroupVersion().WithKind("")]
+}
+
+// Depends declares the kinds which are looked up by the Resolver of the given group, version and kind.
+// Before the objects are resolved, all dependencies of the graphed kinds are listed once per namespace,
+// so the lookups of the resolvers are served from a cache. An empty kind declares the dependencies of
+// all kinds of the group version.
+func Depends(gvk schema.GroupVersionKind, deps ...schema.GroupVersionKind) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+
+	dependencies[gvk] = append(dependencies[gvk], deps...)
+}
+
+// Dependencies returns the kinds which are looked up by the Resolver of the given group, version and kind.
+func Dependencies(gvk schema.GroupVersionKind) []schema.GroupVersionKind {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+
+	deps := append([]schema.GroupVersionKind{}, dependencies[gvk]...)
+	if gvk.Kind != "" {
+		deps = append(deps, dependencies[gvk.GroupVersion().WithKind("")]...)
+	}
+
+	return deps
+}
+
+// Typed returns a Resolver which converts the unstructured object into a new T and passes it to the
+// resolve method of a group graph, e.g. Typed((*Graph).CoreV1, (*CoreV1Graph).Pod).
+func Typed[G any, T any](group func(*Graph) G, resolve func(G, *T) (*Node, error)) Resolver {
+	return ResolverFunc(func(g *Graph, unstr *unstructured.Unstructured) (*Node, error) {
+		obj := new(T)
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return resolve(group(g), obj)
+	})
+}
@@ -15,13 +15,19 @@
 package graph
 
 import (
-	"context"
+	"strconv"
 
 	v1 "github.com/openshift/api/route/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 )
 
+func init() {
+	Register(v1.SchemeGroupVersion.WithKind("Route"), Typed((*Graph).RouteV1, (*RouteV1Graph).Route))
+
+	Depends(v1.SchemeGroupVersion.WithKind("Route"), corev1.SchemeGroupVersion.WithKind("Service"))
+}
+
 // RouteV1Graph is used to graph all routing resources.
 type RouteV1Graph struct {
 	graph *Graph
@@ -39,35 +45,49 @@ func (g *Graph) RouteV1() *RouteV1Graph {
 	return g.routeV1
 }
 
-// Unstructured adds an unstructured node to the Graph.
-func (g *RouteV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
-	switch unstr.GetKind() {
-	case "Route":
-		obj := &v1.Route{}
-		if err := FromUnstructured(unstr, obj); err != nil {
-			return nil, err
-		}
-		return g.Route(obj)
-	default:
-		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
-	}
-}
-
 // Route adds a v1.Route resource to the Graph.
 func (g *RouteV1Graph) Route(obj *v1.Route) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
 
-	options := metav1.GetOptions{}
-	service, err := g.graph.clientset.CoreV1().Services(obj.GetNamespace()).Get(context.TODO(), obj.Spec.To.Name, options)
-	if err != nil {
-		return nil, err
+	if obj.Spec.Host != "" {
+		networking := g.graph.NetworkingV1()
+
+		h, err := networking.Host(obj.Spec.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		r := networking.Relationship(n, networkingv1.PolicyTypeIngress, h)
+		if obj.Spec.Path != "" {
+			r.Attribute("path", obj.Spec.Path)
+		}
+		if obj.Spec.TLS != nil {
+			r.Attribute("termination", string(obj.Spec.TLS.Termination))
+			if obj.Spec.TLS.InsecureEdgeTerminationPolicy != "" {
+				r.Attribute("insecure", string(obj.Spec.TLS.InsecureEdgeTerminationPolicy))
+			}
+		}
 	}
 
-	s, err := g.graph.CoreV1().Service(service)
-	if err != nil {
-		return nil, err
+	backends := append([]v1.RouteTargetReference{obj.Spec.To}, obj.Spec.AlternateBackends...)
+	for _, backend := range backends {
+		if backend.Kind != "" && backend.Kind != "Service" {
+			continue
+		}
+
+		s, err := g.graph.CoreV1().ServiceRef(obj.GetNamespace(), backend.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		r := g.graph.Relationship(n, "Route", s)
+		if backend.Weight != nil && len(backends) > 1 {
+			r.Attribute("weight", strconv.Itoa(int(*backend.Weight)))
+		}
+		if obj.Spec.Port != nil {
+			r.Attribute("port", obj.Spec.Port.TargetPort.String())
+		}
 	}
-	g.graph.Relationship(n, "Route", s)
 
 	return n, nil
 }
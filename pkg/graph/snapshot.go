@@ -0,0 +1,95 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot format. It is increased when the format changes incompatibly.
+const SnapshotVersion = "kubectl-graph/v1"
+
+// Snapshot is the serialized form of a resolved Graph, which can be rendered later without cluster access.
+type Snapshot struct {
+	Version       string          `json:"version"`
+	Cluster       string          `json:"cluster"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Options       *Options        `json:"options,omitempty"`
+	Nodes         []*Node         `json:"nodes"`
+	Relationships []*Relationship `json:"relationships"`
+}
+
+// Snapshot returns the snapshot of the Graph with all nodes and relationships in a stable order.
+func (g *Graph) Snapshot() *Snapshot {
+	return &Snapshot{
+		Version:       SnapshotVersion,
+		Cluster:       g.cluster,
+		Timestamp:     g.created,
+		Options:       g.Options,
+		Nodes:         g.NodeList(),
+		Relationships: g.RelationshipList(),
+	}
+}
+
+// WriteSnapshot writes the snapshot of the Graph as indented JSON to w.
+func (g *Graph) WriteSnapshot(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(g.Snapshot())
+}
+
+// ReadSnapshot reads a snapshot from r and returns its Graph. The Graph can be written in any output format,
+// but it has no objects, so no further objects can be resolved.
+func ReadSnapshot(r io.Reader) (*Graph, error) {
+	snapshot := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if snapshot.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version: %q, supported version is: %s", snapshot.Version, SnapshotVersion)
+	}
+
+	g := newGraph(context.Background(), NewMemorySource(nil), nil, snapshot.Options)
+	g.cluster = snapshot.Cluster
+	g.created = snapshot.Timestamp
+
+	for _, node := range snapshot.Nodes {
+		if node.UID == "" {
+			return nil, fmt.Errorf("invalid snapshot: %s %q has no uid", node.Kind, node.Name)
+		}
+		g.Nodes[node.UID] = node
+		g.index.add(node)
+	}
+
+	for _, r := range snapshot.Relationships {
+		if _, ok := g.Nodes[r.From]; !ok {
+			return nil, fmt.Errorf("invalid snapshot: relationship %s references unknown node %s", r.Label, r.From)
+		}
+		if _, ok := g.Nodes[r.To]; !ok {
+			return nil, fmt.Errorf("invalid snapshot: relationship %s references unknown node %s", r.Label, r.To)
+		}
+		if r.Attr == nil {
+			r.Attr = make(map[string]string)
+		}
+		g.Relationships[r.To] = append(g.Relationships[r.To], r)
+	}
+
+	return g, nil
+}
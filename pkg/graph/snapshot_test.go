@@ -0,0 +1,114 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	g := testGraph(t, 1)
+	g.NodeList()[0].Property("note", `value with "quotes"`)
+
+	b := &bytes.Buffer{}
+	if err := g.WriteSnapshot(b); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadSnapshot(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if read.cluster != g.cluster || !read.created.Equal(g.created) || !reflect.DeepEqual(read.Options, g.Options) {
+		t.Errorf("snapshot header = %s %s %v, want %s %s %v", read.cluster, read.created, read.Options, g.cluster, g.created, g.Options)
+	}
+	nodes := g.NodeList()
+	readNodes := read.NodeList()
+	if len(readNodes) != len(nodes) {
+		t.Fatalf("read %d nodes, want %d", len(readNodes), len(nodes))
+	}
+	for i := range nodes {
+		// empty and nil maps of the metadata can not be told apart after reading, so nodes are compared as JSON
+		got, _ := json.Marshal(readNodes[i])
+		want, _ := json.Marshal(nodes[i])
+		if !bytes.Equal(got, want) || !equalMaps(readNodes[i].Properties, nodes[i].Properties) {
+			t.Errorf("node %d = %s, want %s", i, got, want)
+		}
+	}
+
+	scoped := 0
+	want := g.RelationshipList()
+	got := read.RelationshipList()
+	if len(got) != len(want) {
+		t.Fatalf("read %d relationships, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) || got[i].Key() != want[i].Key() {
+			t.Errorf("relationship %d = %+v, want %+v", i, got[i], want[i])
+		}
+		if len(want[i].Scope) > 0 {
+			scoped++
+		}
+	}
+	if scoped == 0 {
+		t.Error("the test graph has no scoped relationships")
+	}
+
+	again := &bytes.Buffer{}
+	if err := read.WriteSnapshot(again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), b.Bytes()) {
+		t.Errorf("snapshot differs after reading it:\n%s\nwant\n%s", again, b)
+	}
+}
+
+func TestReadSnapshotVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot string
+		err      string
+	}{
+		{
+			name:     "unknown version",
+			snapshot: `{"version": "kubectl-graph/v2", "nodes": [], "relationships": []}`,
+			err:      `unsupported snapshot version: "kubectl-graph/v2"`,
+		},
+		{
+			name:     "missing version",
+			snapshot: `{"nodes": [], "relationships": []}`,
+			err:      `unsupported snapshot version: ""`,
+		},
+		{
+			name:     "not a snapshot",
+			snapshot: `digraph {}`,
+			err:      "invalid snapshot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadSnapshot(strings.NewReader(tt.snapshot))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
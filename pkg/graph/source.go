@@ -0,0 +1,478 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ObjectSource retrieves the objects which are related to the objects of the Graph.
+type ObjectSource interface {
+	// Cluster returns the name of the cluster the objects are retrieved from.
+	Cluster() string
+	// Get returns the object of the given kind, or a NotFound error when it does not exist.
+	Get(ctx context.Context, gvk schema.GroupVersionKind, namespace string, name string) (*unstructured.Unstructured, error)
+	// List returns all objects of the given kind matching the selector. An empty namespace lists all namespaces.
+	List(ctx context.Context, gvk schema.GroupVersionKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error)
+}
+
+// LiveSource is an ObjectSource which retrieves the objects from the API server.
+type LiveSource struct {
+	client  dynamic.Interface
+	mapper  meta.RESTMapper
+	cluster string
+}
+
+// NewLiveSource returns a new initialized LiveSource.
+func NewLiveSource(client dynamic.Interface, mapper meta.RESTMapper, cluster string) *LiveSource {
+	return &LiveSource{
+		client:  client,
+		mapper:  mapper,
+		cluster: cluster,
+	}
+}
+
+// Cluster returns the name of the cluster.
+func (s *LiveSource) Cluster() string {
+	return s.cluster
+}
+
+// Get retrieves an object from the API server.
+func (s *LiveSource) Get(ctx context.Context, gvk schema.GroupVersionKind, namespace string, name string) (*unstructured.Unstructured, error) {
+	r, err := s.resource(gvk, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Get(ctx, name, metav1.GetOptions{})
+}
+
+// List retrieves all objects matching the selector from the API server.
+func (s *LiveSource) List(ctx context.Context, gvk schema.GroupVersionKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	r, err := s.resource(gvk, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := r.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	objs := []*unstructured.Unstructured{}
+	for i := range list.Items {
+		objs = append(objs, &list.Items[i])
+	}
+
+	return objs, nil
+}
+
+// Namespaced returns true when the kind is namespaced.
+func (s *LiveSource) Namespaced(gvk schema.GroupVersionKind) (bool, error) {
+	mapping, err := s.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false, err
+	}
+
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// resource returns the dynamic client for a kind, scoped to the namespace when the kind is namespaced.
+func (s *LiveSource) resource(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	mapping, err := s.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return s.client.Resource(mapping.Resource).Namespace(namespace), nil
+	}
+
+	return s.client.Resource(mapping.Resource), nil
+}
+
+// MemorySource is an ObjectSource which retrieves the objects from an in-memory set of objects,
+// which allows to graph manifests without a cluster. Namespaces which are referenced by the objects
+// are returned even without a manifest, like the API server they are labeled with their name.
+type MemorySource struct {
+	objects []*unstructured.Unstructured
+}
+
+// NewMemorySource returns a new initialized MemorySource.
+func NewMemorySource(objs []*unstructured.Unstructured) *MemorySource {
+	return &MemorySource{
+		objects: objs,
+	}
+}
+
+// Cluster returns local as the name of the cluster.
+func (s *MemorySource) Cluster() string {
+	return "local"
+}
+
+// Get returns an object of the set by group and kind, the version is ignored.
+func (s *MemorySource) Get(ctx context.Context, gvk schema.GroupVersionKind, namespace string, name string) (*unstructured.Unstructured, error) {
+	for _, obj := range s.kind(gvk.GroupKind()) {
+		if obj.GetNamespace() == namespace && obj.GetName() == name {
+			return obj, nil
+		}
+	}
+
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, name)
+}
+
+// List returns all objects of the set by group and kind matching the selector, the version is ignored.
+func (s *MemorySource) List(ctx context.Context, gvk schema.GroupVersionKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+
+	for _, obj := range s.kind(gvk.GroupKind()) {
+		if namespace != metav1.NamespaceAll && obj.GetNamespace() != namespace {
+			continue
+		}
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		objs = append(objs, obj)
+	}
+
+	return objs, nil
+}
+
+// kind returns all objects of the set by group and kind, including the namespaces of all objects.
+func (s *MemorySource) kind(gk schema.GroupKind) []*unstructured.Unstructured {
+	if gk != corev1.SchemeGroupVersion.WithKind("Namespace").GroupKind() {
+		objs := []*unstructured.Unstructured{}
+		for _, obj := range s.objects {
+			if obj.GroupVersionKind().GroupKind() == gk {
+				objs = append(objs, obj)
+			}
+		}
+		return objs
+	}
+
+	namespaces := make(map[string]*unstructured.Unstructured)
+	for _, obj := range s.objects {
+		if obj.GroupVersionKind().GroupKind() == gk {
+			namespaces[obj.GetName()] = obj.DeepCopy()
+		} else if name := obj.GetNamespace(); name != "" && namespaces[name] == nil {
+			namespaces[name] = &unstructured.Unstructured{}
+			namespaces[name].SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+			namespaces[name].SetName(name)
+		}
+	}
+
+	objs := []*unstructured.Unstructured{}
+	for name, namespace := range namespaces {
+		namespaceLabels := namespace.GetLabels()
+		if namespaceLabels == nil {
+			namespaceLabels = make(map[string]string)
+		}
+		namespaceLabels[corev1.LabelMetadataName] = name
+		namespace.SetLabels(namespaceLabels)
+		objs = append(objs, namespace)
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		return objs[i].GetName() < objs[j].GetName()
+	})
+
+	return objs
+}
+
+// InformerSource is an ObjectSource which retrieves the objects from a shared informer cache.
+// An informer is started for each kind on first use and all further lookups are served from its cache.
+type InformerSource struct {
+	factory dynamicinformer.DynamicSharedInformerFactory
+	mapper  meta.RESTMapper
+	cluster string
+	stop    <-chan struct{}
+
+	mu      sync.Mutex
+	listers map[schema.GroupVersionKind]cache.GenericLister
+}
+
+// NewInformerSource returns a new initialized InformerSource. The informers are stopped when stop is closed.
+func NewInformerSource(client dynamic.Interface, mapper meta.RESTMapper, cluster string, resync time.Duration, stop <-chan struct{}) *InformerSource {
+	return &InformerSource{
+		factory: dynamicinformer.NewDynamicSharedInformerFactory(client, resync),
+		mapper:  mapper,
+		cluster: cluster,
+		stop:    stop,
+		listers: make(map[schema.GroupVersionKind]cache.GenericLister),
+	}
+}
+
+// Cluster returns the name of the cluster.
+func (s *InformerSource) Cluster() string {
+	return s.cluster
+}
+
+// Get retrieves an object from the informer cache.
+func (s *InformerSource) Get(ctx context.Context, gvk schema.GroupVersionKind, namespace string, name string) (*unstructured.Unstructured, error) {
+	lister, namespaced, err := s.lister(ctx, gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj runtime.Object
+	if namespaced {
+		obj, err = lister.ByNamespace(namespace).Get(name)
+	} else {
+		obj, err = lister.Get(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	unstr, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T in informer cache", obj)
+	}
+
+	return unstr, nil
+}
+
+// List retrieves all objects matching the selector from the informer cache.
+func (s *InformerSource) List(ctx context.Context, gvk schema.GroupVersionKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	lister, namespaced, err := s.lister(ctx, gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []runtime.Object
+	if namespaced && namespace != metav1.NamespaceAll {
+		list, err = lister.ByNamespace(namespace).List(selector)
+	} else {
+		list, err = lister.List(selector)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	objs := []*unstructured.Unstructured{}
+	for _, obj := range list {
+		unstr, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object type %T in informer cache", obj)
+		}
+		objs = append(objs, unstr)
+	}
+
+	return objs, nil
+}
+
+// Namespaced returns true when the kind is namespaced.
+func (s *InformerSource) Namespaced(gvk schema.GroupVersionKind) (bool, error) {
+	mapping, err := s.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false, err
+	}
+
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// lister returns the lister for a kind and whether the kind is namespaced. The informer of the kind
+// is started and synced on first use.
+func (s *InformerSource) lister(ctx context.Context, gvk schema.GroupVersionKind) (cache.GenericLister, bool, error) {
+	mapping, err := s.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, false, err
+	}
+	namespaced := mapping.Scope.Name() == meta.RESTScopeNameNamespace
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lister, ok := s.listers[gvk]; ok {
+		return lister, namespaced, nil
+	}
+
+	informer := s.factory.ForResource(mapping.Resource)
+	s.factory.Start(s.stop)
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced) {
+		return nil, false, fmt.Errorf("failed to sync informer cache for %s", mapping.Resource)
+	}
+
+	s.listers[gvk] = informer.Lister()
+	return s.listers[gvk], namespaced, nil
+}
+
+// CachedSource is an ObjectSource which serves repeated lookups from a cache. Concurrent lookups
+// of the same objects are retrieved only once from the underlying source, errors are cached as well.
+// Lookups of prefetched kinds are served from the prefetched lists without a request.
+type CachedSource struct {
+	source ObjectSource
+
+	mu         sync.Mutex
+	entries    map[string]*cacheEntry
+	prefetched map[string][]*unstructured.Unstructured
+}
+
+// scoper is implemented by sources which know whether a kind is namespaced.
+type scoper interface {
+	Namespaced(gvk schema.GroupVersionKind) (bool, error)
+}
+
+// cacheEntry is the result of a lookup, done is closed when the lookup has finished.
+type cacheEntry struct {
+	done chan struct{}
+	objs []*unstructured.Unstructured
+	err  error
+}
+
+// NewCachedSource returns a new initialized CachedSource.
+func NewCachedSource(source ObjectSource) *CachedSource {
+	return &CachedSource{
+		source:     source,
+		entries:    make(map[string]*cacheEntry),
+		prefetched: make(map[string][]*unstructured.Unstructured),
+	}
+}
+
+// Cluster returns the name of the cluster of the underlying source.
+func (s *CachedSource) Cluster() string {
+	return s.source.Cluster()
+}
+
+// Prefetch lists all objects of a kind in the namespace once, so all further lookups of the kind in
+// the namespace are served from this list. Cluster scoped kinds are always listed for all namespaces.
+func (s *CachedSource) Prefetch(ctx context.Context, gvk schema.GroupVersionKind, namespace string) error {
+	if scoper, ok := s.source.(scoper); ok {
+		namespaced, err := scoper.Namespaced(gvk)
+		if err != nil {
+			return err
+		}
+		if !namespaced {
+			namespace = metav1.NamespaceAll
+		}
+	}
+
+	objs, err := s.List(ctx, gvk, namespace, labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prefetched[fmt.Sprintf("%s/%s", gvk, namespace)] = objs
+
+	return nil
+}
+
+// lookupPrefetched returns the prefetched objects of a kind in the namespace, and false when the kind was not prefetched for it.
+func (s *CachedSource) lookupPrefetched(gvk schema.GroupVersionKind, namespace string) ([]*unstructured.Unstructured, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if objs, ok := s.prefetched[fmt.Sprintf("%s/%s", gvk, namespace)]; ok {
+		return objs, true
+	}
+
+	all, ok := s.prefetched[fmt.Sprintf("%s/%s", gvk, metav1.NamespaceAll)]
+	if !ok || namespace == metav1.NamespaceAll {
+		return all, ok
+	}
+
+	objs := []*unstructured.Unstructured{}
+	for _, obj := range all {
+		if obj.GetNamespace() == namespace {
+			objs = append(objs, obj)
+		}
+	}
+
+	return objs, true
+}
+
+// Get retrieves an object from the cache or the underlying source.
+func (s *CachedSource) Get(ctx context.Context, gvk schema.GroupVersionKind, namespace string, name string) (*unstructured.Unstructured, error) {
+	if objs, ok := s.lookupPrefetched(gvk, namespace); ok {
+		for _, obj := range objs {
+			if obj.GetName() == name {
+				return obj, nil
+			}
+		}
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, name)
+	}
+
+	key := fmt.Sprintf("get/%s/%s/%s", gvk, namespace, name)
+
+	objs, err := s.do(key, func() ([]*unstructured.Unstructured, error) {
+		obj, err := s.source.Get(ctx, gvk, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		return []*unstructured.Unstructured{obj}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs[0], nil
+}
+
+// List retrieves all objects matching the selector from the cache or the underlying source.
+func (s *CachedSource) List(ctx context.Context, gvk schema.GroupVersionKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	if objs, ok := s.lookupPrefetched(gvk, namespace); ok {
+		selected := []*unstructured.Unstructured{}
+		for _, obj := range objs {
+			if selector.Matches(labels.Set(obj.GetLabels())) {
+				selected = append(selected, obj)
+			}
+		}
+		return selected, nil
+	}
+
+	key := fmt.Sprintf("list/%s/%s/%s", gvk, namespace, selector)
+
+	return s.do(key, func() ([]*unstructured.Unstructured, error) {
+		return s.source.List(ctx, gvk, namespace, selector)
+	})
+}
+
+// do returns the cached result for the key, or performs the lookup. Callers of a lookup which is
+// in progress wait for its result.
+func (s *CachedSource) do(key string, lookup func() ([]*unstructured.Unstructured, error)) ([]*unstructured.Unstructured, error) {
+	s.mu.Lock()
+	if entry, ok := s.entries[key]; ok {
+		s.mu.Unlock()
+		<-entry.done
+		return entry.objs, entry.err
+	}
+
+	entry := &cacheEntry{done: make(chan struct{})}
+	s.entries[key] = entry
+	s.mu.Unlock()
+
+	entry.objs, entry.err = lookup()
+	close(entry.done)
+
+	return entry.objs, entry.err
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const sourceManifests = `
+apiVersion: route.openshift.io/v1
+kind: Route
+metadata:
+  name: web
+  namespace: shop
+  uid: route-web
+spec:
+  to:
+    kind: Service
+    name: web
+  port:
+    targetPort: http
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+  uid: service-web
+spec:
+  type: ClusterIP
+  selector:
+    app: web
+  ports:
+    - name: http
+      port: 80
+---
+apiVersion: v1
+kind: Endpoints
+metadata:
+  name: web
+  namespace: shop
+  uid: endpoints-web
+subsets:
+  - addresses:
+      - ip: 10.0.0.1
+        targetRef:
+          kind: Pod
+          name: web-1
+          namespace: shop
+          uid: pod-web-1
+`
+
+// countingSource counts the lookups which reach the underlying MemorySource.
+type countingSource struct {
+	*MemorySource
+	gets  int
+	lists int
+}
+
+func (s *countingSource) Get(ctx context.Context, gvk schema.GroupVersionKind, namespace string, name string) (*unstructured.Unstructured, error) {
+	s.gets++
+	return s.MemorySource.Get(ctx, gvk, namespace, name)
+}
+
+func (s *countingSource) List(ctx context.Context, gvk schema.GroupVersionKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	s.lists++
+	return s.MemorySource.List(ctx, gvk, namespace, selector)
+}
+
+func TestMemorySource(t *testing.T) {
+	objs := testObjects(t, sourceManifests)
+
+	tests := []struct {
+		name  string
+		graph []*unstructured.Unstructured
+		want  []string
+	}{
+		{
+			name:  "route",
+			graph: objs[:1],
+			want: []string{
+				"Cluster/local Namespace Namespace/shop",
+				"Endpoints/web Pod Pod/web-1",
+				"Namespace/shop Route Route/web",
+				"Route/web Route Service/web port=http",
+				"Service/web Endpoints Endpoints/web",
+			},
+		},
+		{
+			name:  "clusterip service",
+			graph: objs[1:2],
+			want: []string{
+				"Cluster/local Namespace Namespace/shop",
+				"Endpoints/web Pod Pod/web-1",
+				"Namespace/shop Service Service/web",
+				"Service/web Endpoints Endpoints/web",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGraph(context.Background(), NewMemorySource(objs), tt.graph, &Options{Concurrency: 1}, func() {})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := testEdges(g); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("relationships = %q, want %q", got, tt.want)
+			}
+			if len(g.Warnings) != 0 {
+				t.Errorf("warnings = %v, want none", g.Warnings)
+			}
+		})
+	}
+}
+
+func TestCachedSourcePrefetch(t *testing.T) {
+	ctx := context.Background()
+	gvk := corev1.SchemeGroupVersion.WithKind("Service")
+
+	source := &countingSource{MemorySource: NewMemorySource(testObjects(t, sourceManifests))}
+	cached := NewCachedSource(source)
+
+	if err := cached.Prefetch(ctx, gvk, "shop"); err != nil {
+		t.Fatal(err)
+	}
+	if source.lists != 1 {
+		t.Fatalf("prefetch listed %d times, want 1", source.lists)
+	}
+
+	obj, err := cached.Get(ctx, gvk, "shop", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.GetUID() != "service-web" {
+		t.Errorf("get returned %s, want service-web", obj.GetUID())
+	}
+	if _, err := cached.Get(ctx, gvk, "shop", "api"); !apierrors.IsNotFound(err) {
+		t.Errorf("get of a service which was not prefetched returned %v, want not found", err)
+	}
+	if source.gets != 0 {
+		t.Errorf("prefetched gets reached the source %d times, want 0", source.gets)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cached.Get(ctx, gvk, "other", "web"); !apierrors.IsNotFound(err) {
+			t.Errorf("get in another namespace returned %v, want not found", err)
+		}
+	}
+	if source.gets != 1 {
+		t.Errorf("gets of another namespace reached the source %d times, want 1", source.gets)
+	}
+}
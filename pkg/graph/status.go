@@ -0,0 +1,233 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// unhealthyPhases are the phases of pods, volumes and namespaces which are not healthy.
+var unhealthyPhases = map[string]bool{
+	"Pending":     true,
+	"Failed":      true,
+	"Unknown":     true,
+	"Lost":        true,
+	"Terminating": true,
+}
+
+// statusExtractors contains the kind specific extractors of the NodeStatus.
+var statusExtractors = map[schema.GroupKind]func(obj map[string]interface{}, status *NodeStatus){
+	{Group: "", Kind: "Pod"}:                               PodStatus,
+	{Group: "", Kind: "ReplicationController"}:             ReplicasStatus,
+	{Group: "apps", Kind: "Deployment"}:                    ReplicasStatus,
+	{Group: "apps", Kind: "ReplicaSet"}:                    ReplicasStatus,
+	{Group: "apps", Kind: "StatefulSet"}:                   ReplicasStatus,
+	{Group: "apps.openshift.io", Kind: "DeploymentConfig"}: ReplicasStatus,
+	{Group: "apps", Kind: "DaemonSet"}:                     DaemonSetStatus,
+	{Group: "batch", Kind: "Job"}:                          JobStatus,
+	{Group: "", Kind: "ResourceQuota"}:                     ResourceQuotaStatus,
+}
+
+// NodeStatus is the normalized health of a node.
+type NodeStatus struct {
+	Phase             string       `json:"phase,omitempty"`
+	Ready             *bool        `json:"ready,omitempty"`
+	Replicas          *int64       `json:"replicas,omitempty"`
+	ReadyReplicas     *int64       `json:"readyReplicas,omitempty"`
+	Restarts          *int64       `json:"restarts,omitempty"`
+	CreationTimestamp *metav1.Time `json:"creationTimestamp,omitempty"`
+	DeletionTimestamp *metav1.Time `json:"deletionTimestamp,omitempty"`
+	// Used is the usage of a resource quota by resource name.
+	Used map[string]string `json:"used,omitempty"`
+}
+
+// Status extracts the NodeStatus from an object. Typed objects are converted to unstructured first.
+// It returns nil for objects without any metadata, like synthetic nodes.
+func Status(gvk schema.GroupVersionKind, obj metav1.Object) *NodeStatus {
+	var content map[string]interface{}
+
+	switch o := obj.(type) {
+	case *unstructured.Unstructured:
+		content = o.Object
+	case runtime.Object:
+		var err error
+		content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+		if err != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	status := &NodeStatus{}
+
+	if creation := obj.GetCreationTimestamp(); !creation.IsZero() {
+		status.CreationTimestamp = &creation
+	}
+	status.DeletionTimestamp = obj.GetDeletionTimestamp()
+
+	if phase, ok, _ := unstructured.NestedString(content, "status", "phase"); ok {
+		status.Phase = phase
+	}
+	if ready, ok := Condition(content, "Ready"); ok {
+		status.Ready = &ready
+	}
+
+	if extract, ok := statusExtractors[gvk.GroupKind()]; ok {
+		extract(content, status)
+	}
+
+	if len(status.Properties()) == 0 {
+		return nil
+	}
+
+	return status
+}
+
+// PodStatus extracts the restarts of all containers of a pod.
+func PodStatus(obj map[string]interface{}, status *NodeStatus) {
+	restarts := int64(0)
+
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		containers, _, _ := unstructured.NestedSlice(obj, "status", field)
+		for _, container := range containers {
+			if c, ok := container.(map[string]interface{}); ok {
+				count, _, _ := unstructured.NestedInt64(c, "restartCount")
+				restarts += count
+			}
+		}
+	}
+
+	status.Restarts = &restarts
+}
+
+// ReplicasStatus extracts the desired and ready replicas of a workload. Deployments report readiness with the Available condition.
+func ReplicasStatus(obj map[string]interface{}, status *NodeStatus) {
+	replicas, ok, _ := unstructured.NestedInt64(obj, "spec", "replicas")
+	if !ok {
+		replicas = 1
+	}
+	ready, _, _ := unstructured.NestedInt64(obj, "status", "readyReplicas")
+
+	status.Replicas, status.ReadyReplicas = &replicas, &ready
+
+	if available, ok := Condition(obj, "Available"); ok && status.Ready == nil {
+		status.Ready = &available
+	}
+}
+
+// DaemonSetStatus extracts the desired and ready pods of a daemon set.
+func DaemonSetStatus(obj map[string]interface{}, status *NodeStatus) {
+	desired, _, _ := unstructured.NestedInt64(obj, "status", "desiredNumberScheduled")
+	ready, _, _ := unstructured.NestedInt64(obj, "status", "numberReady")
+
+	status.Replicas, status.ReadyReplicas = &desired, &ready
+}
+
+// JobStatus extracts the phase of a job from its Complete and Failed conditions.
+func JobStatus(obj map[string]interface{}, status *NodeStatus) {
+	if complete, ok := Condition(obj, "Complete"); ok && complete {
+		status.Phase = "Complete"
+	}
+	if failed, ok := Condition(obj, "Failed"); ok && failed {
+		status.Phase = "Failed"
+	}
+}
+
+// ResourceQuotaStatus extracts the usage of a resource quota, which changes without a change of its hard limits.
+func ResourceQuotaStatus(obj map[string]interface{}, status *NodeStatus) {
+	used, _, _ := unstructured.NestedStringMap(obj, "status", "used")
+	if len(used) != 0 {
+		status.Used = used
+	}
+}
+
+// Condition returns whether the condition of the given type is true and if it exists.
+func Condition(obj map[string]interface{}, conditionType string) (bool, bool) {
+	conditions, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+
+	for _, condition := range conditions {
+		c, ok := condition.(map[string]interface{})
+		if !ok || c["type"] != conditionType {
+			continue
+		}
+		return c["status"] == string(metav1.ConditionTrue), true
+	}
+
+	return false, false
+}
+
+// Healthy reports whether the node is neither deleted, failing, not ready nor missing replicas.
+func (s *NodeStatus) Healthy() bool {
+	switch {
+	case s == nil:
+		return true
+	case s.DeletionTimestamp != nil:
+		return false
+	case unhealthyPhases[s.Phase]:
+		return false
+	case s.Ready != nil && !*s.Ready:
+		return false
+	case s.Replicas != nil && s.ReadyReplicas != nil && *s.ReadyReplicas < *s.Replicas:
+		return false
+	}
+
+	return true
+}
+
+// Properties returns all fields of the status which are set.
+func (s *NodeStatus) Properties() map[string]interface{} {
+	properties := map[string]interface{}{}
+	if s == nil {
+		return properties
+	}
+
+	if s.Phase != "" {
+		properties["phase"] = s.Phase
+	}
+	if s.Ready != nil {
+		properties["ready"] = *s.Ready
+	}
+	if s.Replicas != nil {
+		properties["replicas"] = *s.Replicas
+	}
+	if s.ReadyReplicas != nil {
+		properties["readyReplicas"] = *s.ReadyReplicas
+	}
+	if s.Restarts != nil {
+		properties["restarts"] = *s.Restarts
+	}
+	if s.CreationTimestamp != nil {
+		properties["creationTimestamp"] = s.CreationTimestamp.UTC().Format(time.RFC3339)
+	}
+	if s.DeletionTimestamp != nil {
+		properties["deletionTimestamp"] = s.DeletionTimestamp.UTC().Format(time.RFC3339)
+	}
+	for name, quantity := range s.Used {
+		properties["used."+name] = quantity
+	}
+
+	return properties
+}
+
+// Healthy reports whether the node has no unhealthy status.
+func (n *Node) Healthy() bool {
+	return n.Status.Healthy()
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// testGraph builds a Graph from the manifests in testdata/graph.yaml without a cluster, resolving the
+// given number of objects concurrently.
+func testGraph(t *testing.T, concurrency int) *Graph {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", "graph.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objs := testObjects(t, string(b))
+
+	g, err := NewGraph(context.Background(), nil, objs, &Options{Concurrency: concurrency}, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return g
+}
+
+func TestTemplates(t *testing.T) {
+	for _, format := range []string{"graphviz", "mermaid", "cypher", "arangodb"} {
+		t.Run(format, func(t *testing.T) {
+			// The output must not depend on the order in which concurrent workers resolve the objects.
+			outputs := map[int][]byte{}
+			for _, concurrency := range []int{1, 8} {
+				b := &bytes.Buffer{}
+				if err := testGraph(t, concurrency).Write(b, format); err != nil {
+					t.Fatal(err)
+				}
+				outputs[concurrency] = b.Bytes()
+
+				testGolden(t, format+".golden", b.Bytes())
+			}
+
+			if !bytes.Equal(outputs[1], outputs[8]) {
+				t.Errorf("output with concurrency 8 differs from concurrency 1:\n%s", outputs[8])
+			}
+		})
+	}
+}
+
+// testGolden compares the output with the golden file in testdata, which is written when -update is set.
+func testGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s, run go test -update to update it:\n%s", golden, got)
+	}
+}
+
+func TestGraphvizEscape(t *testing.T) {
+	g := newGraph(context.Background(), NewMemorySource(nil), nil, &Options{})
+	from := g.Ref(corev1.SchemeGroupVersion.WithKind("Pod"), "shop", `web "canary"`)
+	to := g.Ref(corev1.SchemeGroupVersion.WithKind("Secret"), "shop", "tls")
+	g.Relationship(from, "Rule", to).Attribute("rules", `require "team" label`).Attribute("except", `C:\temp`)
+
+	out := g.String("graphviz")
+	for _, want := range []string{
+		`label="web \"canary\""`,
+		`[label="Rule\nexcept: C:\\temp\nrules: require \"team\" label"`,
+		`labeltooltip="Pod[web \"canary\"] ->\nSecret[tls]"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("graphviz output does not contain %s:\n%s", want, out)
+		}
+	}
+}
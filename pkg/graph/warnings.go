@@ -0,0 +1,106 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"io"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Reasons why an object could not be resolved.
+const (
+	ReasonMissing   = "missing"
+	ReasonForbidden = "forbidden"
+	ReasonFailed    = "failed"
+	// ReasonConflict is reported for relationships whose attributes were set with different values.
+	ReasonConflict = "conflict"
+)
+
+// placeholderProperty is the node property which marks a placeholder node with the reason of the failed lookup.
+const placeholderProperty = "placeholder"
+
+// Warning describes an object which could not be resolved without aborting the Graph.
+type Warning struct {
+	Reason    string
+	Kind      string
+	Namespace string
+	Name      string
+	Err       error
+}
+
+// String returns the warning in a human readable format.
+func (w Warning) String() string {
+	object := w.Kind
+	switch {
+	case w.Name == "" && w.Namespace == "":
+		object += " (all namespaces)"
+	case w.Name == "":
+		object += " (namespace " + w.Namespace + ")"
+	case w.Namespace == "":
+		object += " " + w.Name
+	default:
+		object += " " + w.Namespace + "/" + w.Name
+	}
+
+	return fmt.Sprintf("%-9s %s: %v", w.Reason, object, w.Err)
+}
+
+// Degrade records a NotFound or Forbidden error of a lookup as a warning and returns its reason.
+// Any other error is returned unchanged. An empty name describes a list of objects.
+func (g *Graph) Degrade(gvk schema.GroupVersionKind, namespace string, name string, err error) (string, error) {
+	reason := ""
+	switch {
+	case apierrors.IsNotFound(err):
+		reason = ReasonMissing
+	case apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err):
+		reason = ReasonForbidden
+	default:
+		return "", err
+	}
+
+	g.Warn(Warning{Reason: reason, Kind: gvk.Kind, Namespace: namespace, Name: name, Err: err})
+	return reason, nil
+}
+
+// Warn adds a warning to the Graph, unless the same object was already reported for the same reason.
+func (g *Graph) Warn(w Warning) {
+	for _, warning := range g.Warnings {
+		if warning.Reason == w.Reason && warning.Kind == w.Kind && warning.Namespace == w.Namespace && warning.Name == w.Name {
+			return
+		}
+	}
+
+	g.Warnings = append(g.Warnings, w)
+}
+
+// WriteWarnings writes a summary of all warnings of the Graph.
+func (g *Graph) WriteWarnings(w io.Writer) {
+	if len(g.Warnings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Warning: %d object(s) could not be resolved:\n", len(g.Warnings))
+	for _, warning := range g.Warnings {
+		fmt.Fprintf(w, "  %s\n", warning)
+	}
+}
+
+// Placeholder marks the node as placeholder of an object which could not be resolved for the given reason.
+func (n *Node) Placeholder(reason string) *Node {
+	return n.Property(placeholderProperty, reason)
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// remoteSource serves the objects of a MemorySource like the API server, so the Graph does not treat them as local manifests.
+type remoteSource struct {
+	*MemorySource
+}
+
+func TestMissingEndpoints(t *testing.T) {
+	objs := testObjects(t, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+  uid: service-web
+spec:
+  type: NodePort
+`)
+
+	tests := []struct {
+		name     string
+		source   ObjectSource
+		want     []string
+		warnings []Warning
+	}{
+		{
+			name:   "live",
+			source: &remoteSource{NewMemorySource(objs)},
+			want: []string{
+				"Cluster/local Namespace Namespace/shop",
+				"Namespace/shop Service Service/web",
+				"Service/web Endpoints Endpoints/web",
+			},
+			warnings: []Warning{{Reason: ReasonMissing, Kind: "Endpoints", Namespace: "shop", Name: "web"}},
+		},
+		{
+			name:   "local manifests",
+			source: NewMemorySource(objs),
+			want: []string{
+				"Cluster/local Namespace Namespace/shop",
+				"Namespace/shop Service Service/web",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGraph(context.Background(), tt.source, objs, &Options{Concurrency: 1}, func() {})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := testEdges(g); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("relationships = %q, want %q", got, tt.want)
+			}
+
+			warnings := []Warning{}
+			for _, w := range g.Warnings {
+				w.Err = nil
+				warnings = append(warnings, w)
+			}
+			if len(warnings) != len(tt.warnings) || (len(warnings) > 0 && !reflect.DeepEqual(warnings, tt.warnings)) {
+				t.Errorf("warnings = %v, want %v", warnings, tt.warnings)
+			}
+
+			if len(tt.warnings) > 0 {
+				e := g.FindNode("v1", "Endpoints", "shop", "web")
+				if e == nil || e.Properties[placeholderProperty] != ReasonMissing {
+					t.Errorf("endpoints are not a placeholder marked %s: %v", ReasonMissing, e)
+				}
+			}
+		})
+	}
+}